@@ -0,0 +1,400 @@
+// Command funconv-gen generates reflection-free wrappers with the same
+// conversions and error semantics as funconv.WrapAs.
+//
+// Each argument is a pair of a function and a named function type of the
+// package in the current directory, written as Func:Type. The type may be
+// qualified by an imported package (e.g. Func:endpoint.Endpoint). For each
+// pair, a function named FuncAsType is generated, which returns Func
+// wrapped as Type:
+//
+//	//go:generate funconv-gen -o funconv_gen.go Length:Endpoint
+//
+// Only identical, assignable and interface types are converted. Pairs
+// that need other conversions of funconv.MapConverter (e.g. between
+// structs) are reported as mismatch.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const funconvPath = "github.com/go-midway/midway/funconv"
+
+func main() {
+	output := flag.String("o", "funconv_gen.go", "output file name")
+	dir := flag.String("dir", ".", "directory of the package")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: funconv-gen [flags] Func:Type ...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	pkg, err := loadPackage(*dir, *output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "funconv-gen: %s\n", err.Error())
+		os.Exit(1)
+	}
+	src, err := generate(pkg, flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "funconv-gen: %s\n", err.Error())
+		os.Exit(1)
+	}
+	if err = os.WriteFile(filepath.Join(*dir, *output), src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "funconv-gen: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+// loadPackage type checks the package in dir, ignoring the output
+// file which may be stale. Type errors are ignored, as the package may
+// refer to the wrappers yet to be generated.
+func loadPackage(dir, output string) (pkg *types.Package, err error) {
+	fset := token.NewFileSet()
+	filenames, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return
+	}
+	var files []*ast.File
+	for _, filename := range filenames {
+		if strings.HasSuffix(filename, "_test.go") || filepath.Base(filename) == output {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filename, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+	pkg, _ = conf.Check(files[0].Name.Name, fset, files, nil)
+	return
+}
+
+// lookupType finds a named type in pkg, or in a package imported by pkg
+// if the name is qualified
+func lookupType(pkg *types.Package, name string) (obj types.Object) {
+	scope := pkg.Scope()
+	if i := strings.Index(name, "."); i >= 0 {
+		scope = nil
+		for _, imported := range pkg.Imports() {
+			if imported.Name() == name[:i] {
+				scope = imported.Scope()
+				break
+			}
+		}
+		if scope == nil {
+			return nil
+		}
+		name = name[i+1:]
+	}
+	return scope.Lookup(name)
+}
+
+// generator writes the wrappers of a package
+type generator struct {
+	pkg     *types.Package
+	imports map[string]string
+	buf     bytes.Buffer
+}
+
+// typeString writes typ as in the source code of the generated file
+func (g *generator) typeString(typ types.Type) string {
+	return types.TypeString(typ, func(pkg *types.Package) string {
+		if pkg == g.pkg {
+			return ""
+		}
+		g.imports[pkg.Path()] = pkg.Name()
+		return pkg.Name()
+	})
+}
+
+// reflectString writes typ as reflect.Type.String() does
+func reflectString(typ types.Type) string {
+	return types.TypeString(typ, func(pkg *types.Package) string {
+		return pkg.Name()
+	})
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// conversion is how a value is converted, following funconv.MapConverter
+type conversion int
+
+const (
+	convertDirect conversion = iota
+	convertAssert
+)
+
+func mapConversion(from, to types.Type) (conv conversion, ok bool) {
+	if types.Identical(from, to) || types.AssignableTo(from, to) {
+		return convertDirect, true
+	}
+	if itfce, isItfce := from.Underlying().(*types.Interface); isItfce && types.Implements(to, itfce) {
+		return convertAssert, true
+	}
+	return
+}
+
+func isError(typ types.Type) bool {
+	return typ.String() == "error"
+}
+
+// isNillable reports if nil is a value of typ, which funconv.WrapAs
+// converts nil interfaces to
+func isNillable(typ types.Type) bool {
+	switch typ.Underlying().(type) {
+	case *types.Interface, *types.Pointer, *types.Map, *types.Slice, *types.Signature, *types.Chan:
+		return true
+	}
+	return false
+}
+
+// isSlice reports if typ is a slice type
+func isSlice(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Slice)
+	return ok
+}
+
+// wrapperName returns the name of the wrapper of fn as typ
+func wrapperName(fn, typ string) string {
+	if i := strings.LastIndex(typ, "."); i >= 0 {
+		typ = typ[i+1:]
+	}
+	typ = string(unicode.ToUpper(rune(typ[0]))) + typ[1:]
+	return fn + "As" + typ
+}
+
+func (g *generator) wrap(pair string) error {
+	parts := strings.Split(pair, ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("%q is not in the form of Func:Type", pair)
+	}
+	fnName, typName := parts[0], parts[1]
+
+	fn, ok := g.pkg.Scope().Lookup(fnName).(*types.Func)
+	if !ok {
+		return fmt.Errorf("%s: function not found", fnName)
+	}
+	typ, ok := lookupType(g.pkg, typName).(*types.TypeName)
+	if !ok {
+		return fmt.Errorf("%s: type not found", typName)
+	}
+	src := fn.Type().(*types.Signature)
+	dest, ok := typ.Type().Underlying().(*types.Signature)
+	if !ok {
+		return fmt.Errorf("%s: not a function type", typName)
+	}
+
+	//
+	// validate signatures, as WrapAs does
+	//
+	if want, have := src.Params().Len(), dest.Params().Len(); want != have {
+		return fmt.Errorf("%s: argument mismatch, srcFunc(%d) != destFunc(%d)", pair, want, have)
+	}
+	if want, have := src.Results().Len(), dest.Results().Len(); want != have {
+		return fmt.Errorf("%s: return mismatch, srcFunc(%d) != destFunc(%d)", pair, want, have)
+	}
+	if src.Variadic() != dest.Variadic() {
+		other := dest
+		if !src.Variadic() {
+			other = src
+		}
+		if n := other.Params().Len(); n == 0 || !isSlice(other.Params().At(n-1).Type()) {
+			if src.Variadic() {
+				return fmt.Errorf("%s: srcFunc is variadic function while destFunc is not", pair)
+			}
+			return fmt.Errorf("%s: destFunc is variadic function while srcFunc is not", pair)
+		}
+	}
+
+	numIn, numOut := src.Params().Len(), src.Results().Len()
+	inConvs := make([]conversion, numIn)
+	for i := 0; i < numIn; i++ {
+		from, to := dest.Params().At(i).Type(), src.Params().At(i).Type()
+		if inConvs[i], ok = mapConversion(from, to); !ok {
+			return fmt.Errorf("%s: argument %d, %s cannot be converted %s",
+				pair, i+1, reflectString(from), reflectString(to))
+		}
+	}
+	outConvs := make([]conversion, numOut)
+	for i := 0; i < numOut; i++ {
+		from, to := src.Results().At(i).Type(), dest.Results().At(i).Type()
+		if outConvs[i], ok = mapConversion(from, to); !ok {
+			return fmt.Errorf("%s: return variable %d, %s cannot be converted %s",
+				pair, i+1, reflectString(from), reflectString(to))
+		}
+	}
+
+	errPos := -1
+	for i := numOut - 1; i >= 0; i-- {
+		if isError(dest.Results().At(i).Type()) {
+			errPos = i
+			break
+		}
+	}
+
+	// handleError writes the statements to report a conversion error
+	handleError := func(constructor string, pos int, errExpr string) {
+		if errPos < 0 {
+			g.printf("panic(funconv.%s(%d, %s))\n", constructor, pos, errExpr)
+			return
+		}
+		g.printf("r%d = funconv.%s(%d, %s)\n", errPos, constructor, pos, errExpr)
+		g.printf("return\n")
+	}
+
+	//
+	// write the wrapper
+	//
+	name := wrapperName(fnName, typName)
+	g.printf("// %s wraps %s as %s\n", name, fnName, g.typeString(typ.Type()))
+	g.printf("func %s() %s {\n", name, g.typeString(typ.Type()))
+
+	params := make([]string, numIn)
+	for i := 0; i < numIn; i++ {
+		paramType := dest.Params().At(i).Type()
+		if dest.Variadic() && i == numIn-1 {
+			params[i] = fmt.Sprintf("a%d ...%s", i, g.typeString(paramType.(*types.Slice).Elem()))
+		} else {
+			params[i] = fmt.Sprintf("a%d %s", i, g.typeString(paramType))
+		}
+	}
+	results := make([]string, numOut)
+	for i := 0; i < numOut; i++ {
+		results[i] = fmt.Sprintf("r%d %s", i, g.typeString(dest.Results().At(i).Type()))
+	}
+	g.printf("return func(%s) (%s) {\n", strings.Join(params, ", "), strings.Join(results, ", "))
+
+	// convert arguments
+	args := make([]string, numIn)
+	for i := 0; i < numIn; i++ {
+		args[i] = fmt.Sprintf("a%d", i)
+		if inConvs[i] != convertAssert {
+			continue
+		}
+		to := src.Params().At(i).Type()
+		args[i] = fmt.Sprintf("b%d", i)
+		g.printf("b%d, ok := a%d.(%s)\n", i, i, g.typeString(to))
+		if isNillable(to) {
+			g.printf("if !ok && a%d != nil {\n", i)
+		} else {
+			g.printf("if !ok {\n")
+		}
+		handleError("NewArgumentError", i,
+			fmt.Sprintf("fmt.Errorf(\"%%T cannot be converted to %%s\", a%d, %q)", i, reflectString(to)))
+		g.printf("}\n")
+		g.imports["fmt"] = "fmt"
+	}
+	if src.Variadic() {
+		args[numIn-1] += "..."
+	}
+
+	// call the function
+	call := fmt.Sprintf("%s(%s)", fnName, strings.Join(args, ", "))
+	if numOut == 0 {
+		g.printf("%s\n", call)
+		g.printf("return\n")
+		g.printf("}\n}\n\n")
+		return nil
+	}
+	rets := make([]string, numOut)
+	for i := range rets {
+		rets[i] = fmt.Sprintf("s%d", i)
+	}
+	g.printf("%s := %s\n", strings.Join(rets, ", "), call)
+
+	// convert return variables
+	for i := 0; i < numOut; i++ {
+		if outConvs[i] != convertAssert {
+			g.printf("r%d = s%d\n", i, i)
+			continue
+		}
+		to := dest.Results().At(i).Type()
+		if isNillable(to) {
+			g.printf("if v, ok := s%d.(%s); ok || s%d == nil {\n", i, g.typeString(to), i)
+		} else {
+			g.printf("if v, ok := s%d.(%s); ok {\n", i, g.typeString(to))
+		}
+		g.printf("r%d = v\n", i)
+		g.printf("} else {\n")
+		handleError("NewRetVarError", i,
+			fmt.Sprintf("fmt.Errorf(\"%%T cannot be converted to %%s\", s%d, %q)", i, reflectString(to)))
+		g.printf("}\n")
+		g.imports["fmt"] = "fmt"
+	}
+	g.printf("return\n")
+	g.printf("}\n}\n\n")
+	return nil
+}
+
+// generate returns the formatted source of the wrappers of pairs
+// for pkg
+func generate(pkg *types.Package, pairs []string) (src []byte, err error) {
+	g := &generator{
+		pkg:     pkg,
+		imports: map[string]string{funconvPath: "funconv"},
+	}
+	for _, pair := range pairs {
+		if err = g.wrap(pair); err != nil {
+			return
+		}
+	}
+
+	// the wrappers may not report errors, and need not funconv
+	body := g.buf.String()
+	if !strings.Contains(body, "funconv.") {
+		delete(g.imports, funconvPath)
+	}
+
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var file bytes.Buffer
+	fmt.Fprintf(&file, "// Code generated by funconv-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&file, "package %s\n\n", pkg.Name())
+	if len(paths) > 0 {
+		// standard library first, then the others
+		fmt.Fprintf(&file, "import (\n")
+		for _, std := range []bool{true, false} {
+			for _, path := range paths {
+				if isStd := !strings.Contains(strings.Split(path, "/")[0], "."); isStd == std {
+					fmt.Fprintf(&file, "%q\n", path)
+				}
+			}
+			fmt.Fprintf(&file, "\n")
+		}
+		fmt.Fprintf(&file, ")\n\n")
+	}
+	file.WriteString(body)
+
+	return format.Source(file.Bytes())
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const gentestDir = "../../funconv/internal/gentest"
+
+// generateArgs reads the funconv-gen arguments of the go:generate
+// directive in gentest
+func generateArgs(t *testing.T) (output string, pairs []string) {
+	src, err := os.ReadFile(filepath.Join(gentestDir, "gentest.go"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	for _, line := range strings.Split(string(src), "\n") {
+		if !strings.HasPrefix(line, "//go:generate ") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			switch {
+			case fields[i] == "-o":
+				i++
+				output = fields[i]
+			case strings.Contains(fields[i], ":") && !strings.HasPrefix(fields[i], "//"):
+				pairs = append(pairs, fields[i])
+			}
+		}
+		return
+	}
+	t.Fatalf("go:generate directive not found")
+	return
+}
+
+// loadGentest type checks gentest, which takes long for the standard
+// library is type checked from source
+func loadGentest(t *testing.T, output string) *types.Package {
+	pkg, err := loadPackage(gentestDir, output)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	return pkg
+}
+
+func TestGenerate_upToDate(t *testing.T) {
+	output, pairs := generateArgs(t)
+	src, err := generate(loadGentest(t, output), pairs)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	committed, err := os.ReadFile(filepath.Join(gentestDir, output))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !bytes.Equal(src, committed) {
+		t.Errorf("%s is out of date, run go generate", output)
+	}
+}
+
+func TestGenerate_error(t *testing.T) {
+	tests := []struct {
+		pair string
+		want string
+	}{
+		{
+			pair: "Length",
+			want: `"Length" is not in the form of Func:Type`,
+		},
+		{
+			pair: "NoSuchFunc:LengthFunc",
+			want: "NoSuchFunc: function not found",
+		},
+		{
+			pair: "Length:NoSuchType",
+			want: "NoSuchType: type not found",
+		},
+		{
+			pair: "Length:stringer",
+			want: "stringer: not a function type",
+		},
+		{
+			pair: "Length:Endpoint",
+			want: "Length:Endpoint: argument mismatch, srcFunc(1) != destFunc(2)",
+		},
+		{
+			pair: "Check:LengthFunc",
+			want: "Check:LengthFunc: return variable 1, error cannot be converted int",
+		},
+		{
+			pair: "Describe:LengthFunc",
+			want: "Describe:LengthFunc: argument 1, string cannot be converted gentest.stringer",
+		},
+		{
+			pair: "CountNames:LengthEndpoint",
+			want: "CountNames:LengthEndpoint: srcFunc is variadic function while destFunc is not",
+		},
+	}
+	pkg := loadGentest(t, "gentest_gen.go")
+	for _, test := range tests {
+		_, err := generate(pkg, []string{test.pair})
+		if err == nil {
+			t.Errorf("%s: expected error, got nil", test.pair)
+		} else if want, have := test.want, err.Error(); want != have {
+			t.Errorf("%s: expected %#v, got %#v", test.pair, want, have)
+		}
+	}
+}
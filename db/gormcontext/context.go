@@ -2,6 +2,9 @@ package gormcontext
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/jinzhu/gorm"
 )
@@ -11,8 +14,17 @@ type contextStrKey string
 
 const (
 	dbCtxKey contextKey = iota
+	queryCounterCtxKey
+	namesCtxKey
+	txCtxKey
 )
 
+// DBName identifies a named *gorm.DB in the context. Declare the names
+// as constants once, so typos are caught by the compiler:
+//
+//	const ReportDB gormcontext.DBName = "report"
+type DBName string
+
 // WithDB inserts a *gorm.DB into the context
 func WithDB(parent context.Context, db *gorm.DB) context.Context {
 	return context.WithValue(parent, dbCtxKey, db)
@@ -24,13 +36,53 @@ func GetDB(ctx context.Context) (db *gorm.DB) {
 	return
 }
 
-// WithNamedDB inserts a named *gorm.DB into the context, identified by string name
-func WithNamedDB(parent context.Context, name string, db *gorm.DB) context.Context {
-	return context.WithValue(parent, contextStrKey(name), db)
+// WithNamedDB inserts a named *gorm.DB into the context, identified by name
+func WithNamedDB(parent context.Context, name DBName, db *gorm.DB) context.Context {
+	names := Names(parent)
+	if !hasName(names, name) {
+		names = append(names, name)
+	}
+	ctx := context.WithValue(parent, namesCtxKey, names)
+	return context.WithValue(ctx, contextStrKey(name), db)
 }
 
 // GetNamedDB returns a named *gorm.DB or nil if the context have none
-func GetNamedDB(ctx context.Context, name string) (db *gorm.DB) {
+func GetNamedDB(ctx context.Context, name DBName) (db *gorm.DB) {
 	db, _ = ctx.Value(contextStrKey(name)).(*gorm.DB)
 	return
 }
+
+// MustGetNamedDB returns a named *gorm.DB, or panics with the list
+// of registered names if the context have none
+func MustGetNamedDB(ctx context.Context, name DBName) (db *gorm.DB) {
+	if db = GetNamedDB(ctx, name); db == nil {
+		names := Names(ctx)
+		quoted := make([]string, len(names))
+		for i := range names {
+			quoted[i] = fmt.Sprintf("%q", names[i])
+		}
+		panic(fmt.Sprintf("gormcontext: no *gorm.DB named %q in context, registered names: [%s]",
+			name, strings.Join(quoted, ", ")))
+	}
+	return
+}
+
+// Names returns the sorted names of every named *gorm.DB in the context
+func Names(ctx context.Context) (names []DBName) {
+	stored, _ := ctx.Value(namesCtxKey).([]DBName)
+	names = make([]DBName, len(stored), len(stored)+1)
+	copy(names, stored)
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return
+}
+
+func hasName(names []DBName, name DBName) bool {
+	for i := range names {
+		if names[i] == name {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package gormcontext_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/go-midway/midway/db/gormcontext"
@@ -78,3 +79,53 @@ func TestWithNamedDB(t *testing.T) {
 		t.Errorf("expected %#v, got %#v", want, have)
 	}
 }
+
+func TestMustGetNamedDB(t *testing.T) {
+	const (
+		reportDB gormcontext.DBName = "report"
+		userDB   gormcontext.DBName = "user"
+	)
+
+	dbSrc, err := gorm.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	ctx := gormcontext.WithNamedDB(context.Background(), userDB, dbSrc)
+	ctx = gormcontext.WithNamedDB(ctx, reportDB, dbSrc)
+
+	if want, have := dbSrc, gormcontext.MustGetNamedDB(ctx, reportDB); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Errorf("expected panic, got nil")
+			return
+		}
+		if want, have := `gormcontext: no *gorm.DB named "reports" in context, registered names: ["report", "user"]`, r; want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+	}()
+	gormcontext.MustGetNamedDB(ctx, "reports")
+}
+
+func TestNames(t *testing.T) {
+	ctx := context.Background()
+	if want, have := 0, len(gormcontext.Names(ctx)); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	ctx = gormcontext.WithNamedDB(ctx, "name 2", nil)
+	ctx1 := gormcontext.WithNamedDB(ctx, "name 1", nil)
+	ctx2 := gormcontext.WithNamedDB(ctx, "name 3", nil)
+	ctx2 = gormcontext.WithNamedDB(ctx2, "name 2", nil)
+
+	if want, have := "[name 1 name 2]", fmt.Sprintf("%s", gormcontext.Names(ctx1)); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "[name 2 name 3]", fmt.Sprintf("%s", gormcontext.Names(ctx2)); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
@@ -0,0 +1,41 @@
+package gormcontext
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-midway/midway/health"
+	"github.com/jinzhu/gorm"
+)
+
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// DBChecker returns a health.Checker that pings the database of db
+func DBChecker(db *gorm.DB) health.Checker {
+	return health.CheckerFunc(func(ctx context.Context) error {
+		conn, ok := db.CommonDB().(pinger)
+		if !ok {
+			return fmt.Errorf("%T cannot be pinged", db.CommonDB())
+		}
+		return conn.PingContext(ctx)
+	})
+}
+
+// DBCheckers is a health.Source that checks every *gorm.DB put into
+// the request context by ApplyDB (as "db") and ApplyNamedDB (as "db:name")
+func DBCheckers(r *http.Request) map[string]health.Checker {
+	ctx := r.Context()
+	checkers := make(map[string]health.Checker)
+	if db := GetDB(ctx); db != nil {
+		checkers["db"] = DBChecker(db)
+	}
+	for _, name := range Names(ctx) {
+		if db := GetNamedDB(ctx, name); db != nil {
+			checkers["db:"+string(name)] = DBChecker(db)
+		}
+	}
+	return checkers
+}
@@ -0,0 +1,57 @@
+package gormcontext_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-midway/midway"
+	"github.com/go-midway/midway/db/gormcontext"
+	"github.com/go-midway/midway/health"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestDBCheckers(t *testing.T) {
+	var dbSrc, dbClosed *gorm.DB
+	var err error
+
+	if dbSrc, err = gorm.Open("sqlite3", ":memory:"); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if dbClosed, err = gorm.Open("sqlite3", ":memory:"); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	dbClosed.Close()
+
+	handler := midway.Chain(
+		gormcontext.ApplyDB(dbSrc),
+		gormcontext.ApplyNamedDB(dbSrc, "report"),
+		gormcontext.ApplyNamedDB(dbClosed, "closed"),
+	)(health.Handler(health.Options{}, gormcontext.DBCheckers))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/readyz", nil)
+	handler.ServeHTTP(w, r)
+
+	if want, have := http.StatusServiceUnavailable, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	var report health.Report
+	if err = json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	for name, status := range map[string]string{
+		"db":        "ok",
+		"db:report": "ok",
+		"db:closed": "error",
+	} {
+		if want, have := status, report.Checks[name].Status; want != have {
+			t.Errorf("check %#v: expected %#v, got %#v", name, want, have)
+		}
+	}
+}
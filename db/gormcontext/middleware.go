@@ -17,10 +17,24 @@ func ApplyDB(db *gorm.DB) midway.Middleware {
 }
 
 // ApplyNamedDB puts a *gorm.DB into the context for inner handler
-func ApplyNamedDB(db *gorm.DB, name string) midway.Middleware {
+func ApplyNamedDB(db *gorm.DB, name DBName) midway.Middleware {
 	return func(inner http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			inner.ServeHTTP(w, r.WithContext(WithNamedDB(r.Context(), name, db)))
 		})
 	}
 }
+
+// ApplyNamedDBs puts every *gorm.DB in dbs into the context for inner
+// handler, each identified by its key
+func ApplyNamedDBs(dbs map[DBName]*gorm.DB) midway.Middleware {
+	return func(inner http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			for name, db := range dbs {
+				ctx = WithNamedDB(ctx, name, db)
+			}
+			inner.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -60,3 +60,40 @@ func TestApplyNamedDB(t *testing.T) {
 	r, _ := http.NewRequest("GET", "/foobar", nil)
 	handler.ServeHTTP(w, r)
 }
+
+func TestApplyNamedDBs(t *testing.T) {
+	var dbSrc1, dbSrc2 *gorm.DB
+	var err error
+
+	if dbSrc1, err = gorm.Open("sqlite3", ":memory:"); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if dbSrc2, err = gorm.Open("sqlite3", ":memory:"); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+
+	handler := gormcontext.ApplyNamedDBs(map[gormcontext.DBName]*gorm.DB{
+		"name 1": dbSrc1,
+		"name 2": dbSrc2,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, have := dbSrc1, gormcontext.GetNamedDB(r.Context(), "name 1"); want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+		if want, have := dbSrc2, gormcontext.GetNamedDB(r.Context(), "name 2"); want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+		if want, have := "[name 1 name 2]", fmt.Sprintf("%s", gormcontext.Names(r.Context())); want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+		fmt.Fprintf(w, "success")
+	}))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/foobar", nil)
+	handler.ServeHTTP(w, r)
+	if want, have := "success", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
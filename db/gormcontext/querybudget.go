@@ -0,0 +1,323 @@
+package gormcontext
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/go-midway/midway"
+	"github.com/go-midway/midway/logcontext"
+	"github.com/jinzhu/gorm"
+)
+
+// QueryStat summarizes the executions of an identical SQL statement
+type QueryStat struct {
+	SQL      string
+	Count    int
+	Distinct int
+}
+
+// QueryCounter counts SQL statements executed through the *gorm.DB
+// returned by InstrumentDB
+type QueryCounter struct {
+	mu     sync.Mutex
+	count  int
+	order  []string
+	stats  map[string]*QueryStat
+	params map[string]map[string]struct{}
+}
+
+// NewQueryCounter returns an empty *QueryCounter
+func NewQueryCounter() *QueryCounter {
+	return &QueryCounter{
+		stats:  make(map[string]*QueryStat),
+		params: make(map[string]map[string]struct{}),
+	}
+}
+
+func (counter *QueryCounter) add(sql string, vars interface{}) {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+
+	counter.count++
+	stat, ok := counter.stats[sql]
+	if !ok {
+		stat = &QueryStat{SQL: sql}
+		counter.stats[sql] = stat
+		counter.params[sql] = make(map[string]struct{})
+		counter.order = append(counter.order, sql)
+	}
+	stat.Count++
+
+	key := fmt.Sprintf("%#v", vars)
+	if _, seen := counter.params[sql][key]; !seen {
+		counter.params[sql][key] = struct{}{}
+		stat.Distinct++
+	}
+}
+
+// Count returns the number of statements executed so far
+func (counter *QueryCounter) Count() int {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	return counter.count
+}
+
+// Stats returns statistics of every statement executed so far,
+// in the order of their first execution
+func (counter *QueryCounter) Stats() []QueryStat {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	stats := make([]QueryStat, len(counter.order))
+	for i, sql := range counter.order {
+		stats[i] = *counter.stats[sql]
+	}
+	return stats
+}
+
+// Repeated returns statistics of the statements executed with more
+// than max distinct sets of parameters, which is a sign of N+1 queries
+func (counter *QueryCounter) Repeated(max int) (stats []QueryStat) {
+	for _, stat := range counter.Stats() {
+		if stat.Distinct > max {
+			stats = append(stats, stat)
+		}
+	}
+	return
+}
+
+// Printer is the logger interface of gorm, which gorm.Logger implements
+type Printer interface {
+	Print(v ...interface{})
+}
+
+// counterLogger implements gorm's logger to receive every
+// executed statement. The messages are forwarded to next, every
+// message if detailed, or else only the errors.
+type counterLogger struct {
+	counter  *QueryCounter
+	next     Printer
+	detailed bool
+}
+
+func (logger counterLogger) Print(v ...interface{}) {
+	// gorm prints statements as:
+	// "sql", file:line, duration, sql, vars, rows affected
+	if len(v) >= 5 && v[0] == "sql" {
+		if sql, ok := v[3].(string); ok {
+			logger.counter.add(sql, v[4])
+		}
+	}
+
+	if logger.next == nil {
+		return
+	}
+	if logger.detailed {
+		logger.next.Print(v...)
+		return
+	}
+	// errors are printed as "log", file:line, err in detailed mode,
+	// and as "error", file:line, err otherwise
+	if len(v) == 3 && v[0] == "log" {
+		if err, ok := v[2].(error); ok {
+			logger.next.Print("error", v[1], err)
+		}
+	}
+}
+
+// InstrumentDB returns a copy of db which reports every statement
+// executed through it, or any *gorm.DB derived from it, to counter.
+// The copy keeps the conditions of db.
+//
+// The logger of db is replaced, as gorm has no way to read it. The
+// messages are printed with logger instead, every statement if detailed,
+// as db.LogMode(true) does, or else only the errors. Nil logger
+// discards them.
+func InstrumentDB(db *gorm.DB, counter *QueryCounter, logger Printer, detailed bool) *gorm.DB {
+	instrumented := db.Set("gormcontext:query_counter", counter)
+	instrumented.SetLogger(counterLogger{counter: counter, next: logger, detailed: detailed})
+	instrumented.LogMode(true)
+	return instrumented
+}
+
+// WithQueryCounter inserts a *QueryCounter into the context
+func WithQueryCounter(parent context.Context, counter *QueryCounter) context.Context {
+	return context.WithValue(parent, queryCounterCtxKey, counter)
+}
+
+// GetQueryCounter returns a *QueryCounter or nil if the context have none
+func GetQueryCounter(ctx context.Context) (counter *QueryCounter) {
+	counter, _ = ctx.Value(queryCounterCtxKey).(*QueryCounter)
+	return
+}
+
+// QueryBudget configures ApplyQueryBudget
+type QueryBudget struct {
+	// MaxQueries is the number of statements a request may execute.
+	// Zero means no limit.
+	MaxQueries int
+
+	// MaxRepeats is the number of distinct parameter sets an identical
+	// statement may be executed with before it is reported as N+1 queries.
+	// Zero disables the detection.
+	MaxRepeats int
+
+	// Header adds the X-Query-Count header to the response
+	Header bool
+
+	// Strict fails the request with 500 Internal Server Error when
+	// the budget is exceeded. The response is buffered to do so, hence
+	// it is meant for tests only.
+	Strict bool
+
+	// Logger prints the messages of gorm in place of the logger of the
+	// *gorm.DB in the context, which InstrumentDB replaces. Nil discards
+	// them.
+	Logger Printer
+
+	// LogMode prints every statement with Logger, as gorm.DB.LogMode(true)
+	// does. Otherwise only the errors are printed.
+	LogMode bool
+}
+
+// violations returns a description of each budget violation
+func (budget QueryBudget) violations(counter *QueryCounter) (msgs []string) {
+	if budget.MaxQueries > 0 {
+		if count := counter.Count(); count > budget.MaxQueries {
+			msgs = append(msgs, fmt.Sprintf(
+				"%d queries exceed budget of %d", count, budget.MaxQueries))
+		}
+	}
+	if budget.MaxRepeats > 0 {
+		for _, stat := range counter.Repeated(budget.MaxRepeats) {
+			msgs = append(msgs, fmt.Sprintf(
+				"possible N+1 queries, %d executions with %d distinct parameters: %s",
+				stat.Count, stat.Distinct, stat.SQL))
+		}
+	}
+	return
+}
+
+// headerWriter sets the X-Query-Count header before the
+// response header is written
+type headerWriter struct {
+	http.ResponseWriter
+	counter     *QueryCounter
+	wroteHeader bool
+}
+
+func (w *headerWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set("X-Query-Count", strconv.Itoa(w.counter.Count()))
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *headerWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// bufferedWriter holds the response until the budget is checked
+type bufferedWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+func (w *bufferedWriter) flush(dest http.ResponseWriter) {
+	for key, values := range w.header {
+		dest.Header()[key] = values
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	dest.WriteHeader(w.code)
+	dest.Write(w.body.Bytes())
+}
+
+// ApplyQueryBudget instruments the *gorm.DB in the context, so statements
+// executed by inner handler are counted against the budget. Violations are
+// logged as warning with the logger of logcontext.
+//
+// It needs to be chained after ApplyDB.
+func ApplyQueryBudget(budget QueryBudget) midway.Middleware {
+	return func(inner http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			db := GetDB(r.Context())
+			if db == nil {
+				inner.ServeHTTP(w, r)
+				return
+			}
+
+			counter := NewQueryCounter()
+			ctx := WithDB(r.Context(), InstrumentDB(db, counter, budget.Logger, budget.LogMode))
+			ctx = WithQueryCounter(ctx, counter)
+			r = r.WithContext(ctx)
+
+			var buf *bufferedWriter
+			switch {
+			case budget.Strict:
+				buf = &bufferedWriter{header: make(http.Header)}
+				inner.ServeHTTP(buf, r)
+			case budget.Header:
+				inner.ServeHTTP(&headerWriter{ResponseWriter: w, counter: counter}, r)
+			default:
+				inner.ServeHTTP(w, r)
+			}
+
+			msgs := budget.violations(counter)
+			logger := logcontext.GetLogger(ctx)
+			for _, msg := range msgs {
+				logger.Log(
+					"at", "warning",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"msg", msg,
+				)
+			}
+
+			if buf == nil {
+				return
+			}
+			if budget.Header {
+				buf.header.Set("X-Query-Count", strconv.Itoa(counter.Count()))
+			}
+			if len(msgs) > 0 {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				if budget.Header {
+					w.Header().Set("X-Query-Count", strconv.Itoa(counter.Count()))
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+				for _, msg := range msgs {
+					fmt.Fprintln(w, msg)
+				}
+				return
+			}
+			buf.flush(w)
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package gormcontext_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-midway/midway"
+	"github.com/go-midway/midway/db/gormcontext"
+	"github.com/go-midway/midway/logcontext"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+type budgetUser struct {
+	ID   int
+	Name string
+}
+
+func openBudgetDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	db.DB().SetMaxOpenConns(1)
+	if err = db.AutoMigrate(&budgetUser{}).Error; err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	for i := 1; i <= 3; i++ {
+		db.Create(&budgetUser{ID: i, Name: fmt.Sprintf("user %d", i)})
+	}
+	return db
+}
+
+// nPlusOne queries every user one by one
+var nPlusOne = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	db := gormcontext.GetDB(r.Context())
+	var users []budgetUser
+	db.Find(&users)
+	for _, user := range users {
+		var found budgetUser
+		db.Where("id = ?", user.ID).First(&found)
+	}
+	fmt.Fprintf(w, "success")
+})
+
+func TestQueryCounter(t *testing.T) {
+	db := openBudgetDB(t)
+	counter := gormcontext.NewQueryCounter()
+	instrumented := gormcontext.InstrumentDB(db, counter, nil, false)
+
+	var user1, user2, user3 budgetUser
+	instrumented.Where("id = ?", 1).First(&user1)
+	instrumented.Where("id = ?", 1).First(&user2)
+	instrumented.Where("id = ?", 2).First(&user3)
+	instrumented.Exec("UPDATE budget_users SET name = ?", "hello")
+
+	if want, have := 4, counter.Count(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	stats := counter.Stats()
+	if want, have := 2, len(stats); want != have {
+		t.Fatalf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 3, stats[0].Count; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 2, stats[0].Distinct; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	repeated := counter.Repeated(1)
+	if want, have := 1, len(repeated); want != have {
+		t.Fatalf("expected %#v, got %#v", want, have)
+	}
+	if want, have := stats[0].SQL, repeated[0].SQL; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// the original db is not instrumented
+	var user4 budgetUser
+	db.First(&user4)
+	if want, have := 4, counter.Count(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+// recordLogger records the messages printed by gorm
+type recordLogger struct {
+	msgs []string
+}
+
+func (logger *recordLogger) Print(v ...interface{}) {
+	logger.msgs = append(logger.msgs, fmt.Sprint(v[0]))
+}
+
+func TestInstrumentDB_scopes(t *testing.T) {
+	db := openBudgetDB(t)
+	counter := gormcontext.NewQueryCounter()
+	instrumented := gormcontext.InstrumentDB(db.Where("id > ?", 1), counter, nil, false)
+
+	var users []budgetUser
+	instrumented.Find(&users)
+	if want, have := 2, len(users); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 1, counter.Count(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestInstrumentDB_logger(t *testing.T) {
+	db := openBudgetDB(t)
+
+	// statements are printed in detailed mode
+	detailed := &recordLogger{}
+	instrumented := gormcontext.InstrumentDB(db, gormcontext.NewQueryCounter(), detailed, true)
+	instrumented.First(&budgetUser{})
+	if want, have := "sql", strings.Join(detailed.msgs, ","); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// only errors are printed otherwise
+	logger := &recordLogger{}
+	instrumented = gormcontext.InstrumentDB(db, gormcontext.NewQueryCounter(), logger, false)
+	instrumented.First(&budgetUser{})
+	instrumented.Table("no_such_table").First(&budgetUser{})
+	if want, have := "error", strings.Join(logger.msgs, ","); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// the logger of db is not used
+	unused := &recordLogger{}
+	db.SetLogger(unused)
+	db.LogMode(true)
+	instrumented = gormcontext.InstrumentDB(db, gormcontext.NewQueryCounter(), nil, false)
+	instrumented.First(&budgetUser{})
+	if want, have := 0, len(unused.msgs); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestApplyQueryBudget_logger(t *testing.T) {
+	db := openBudgetDB(t)
+	logger := &recordLogger{}
+	handler := midway.Chain(
+		gormcontext.ApplyDB(db),
+		gormcontext.ApplyQueryBudget(gormcontext.QueryBudget{
+			Logger:  logger,
+			LogMode: true,
+		}),
+	)(nPlusOne)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/foobar", nil)
+	handler.ServeHTTP(w, r)
+
+	if want, have := "sql,sql,sql,sql", strings.Join(logger.msgs, ","); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestApplyQueryBudget_header(t *testing.T) {
+	db := openBudgetDB(t)
+	handler := midway.Chain(
+		gormcontext.ApplyDB(db),
+		gormcontext.ApplyQueryBudget(gormcontext.QueryBudget{Header: true}),
+	)(nPlusOne)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/foobar", nil)
+	handler.ServeHTTP(w, r)
+
+	if want, have := "4", w.Header().Get("X-Query-Count"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "success", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestApplyQueryBudget_warning(t *testing.T) {
+	db := openBudgetDB(t)
+	buf := &bytes.Buffer{}
+	handler := midway.Chain(
+		logcontext.ApplyLogger(func() kitlog.Logger {
+			return kitlog.NewLogfmtLogger(buf)
+		}),
+		gormcontext.ApplyDB(db),
+		gormcontext.ApplyQueryBudget(gormcontext.QueryBudget{
+			MaxQueries: 2,
+			MaxRepeats: 2,
+		}),
+	)(nPlusOne)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/foobar", nil)
+	handler.ServeHTTP(w, r)
+
+	if want, have := http.StatusOK, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "", w.Header().Get("X-Query-Count"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	logs := buf.String()
+	if want := `msg="4 queries exceed budget of 2"`; !strings.Contains(logs, want) {
+		t.Errorf("expected log to contain %#v, got %#v", want, logs)
+	}
+	if want := `possible N+1 queries, 3 executions with 3 distinct parameters`; !strings.Contains(logs, want) {
+		t.Errorf("expected log to contain %#v, got %#v", want, logs)
+	}
+}
+
+func TestApplyQueryBudget_strict(t *testing.T) {
+	db := openBudgetDB(t)
+	handler := midway.Chain(
+		logcontext.ApplyLogger(func() kitlog.Logger {
+			return kitlog.NewNopLogger()
+		}),
+		gormcontext.ApplyDB(db),
+		gormcontext.ApplyQueryBudget(gormcontext.QueryBudget{
+			MaxQueries: 2,
+			Header:     true,
+			Strict:     true,
+		}),
+	)(nPlusOne)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/foobar", nil)
+	handler.ServeHTTP(w, r)
+
+	if want, have := http.StatusInternalServerError, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "4", w.Header().Get("X-Query-Count"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "4 queries exceed budget of 2\n", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// within budget, the buffered response is passed through
+	handler = midway.Chain(
+		gormcontext.ApplyDB(db),
+		gormcontext.ApplyQueryBudget(gormcontext.QueryBudget{
+			MaxQueries: 10,
+			Header:     true,
+			Strict:     true,
+		}),
+	)(nPlusOne)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if want, have := http.StatusOK, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "4", w.Header().Get("X-Query-Count"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "success", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
@@ -0,0 +1,179 @@
+package gormcontext
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-midway/midway/logcontext"
+)
+
+// Retryable reports if err is a transient failure and the
+// transaction is worth retrying
+type Retryable func(err error) bool
+
+// errorField finds the named field of the first error in the chain
+// of err which is a struct, or pointer to struct, of the packages with
+// such field. Driver errors are inspected this way so the drivers need
+// not be imported.
+func errorField(err error, name string, pkgPaths ...string) (field reflect.Value, ok bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		val := reflect.ValueOf(err)
+		if val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+		if val.Kind() != reflect.Struct || !inPackages(val.Type().PkgPath(), pkgPaths) {
+			continue
+		}
+		if field = val.FieldByName(name); field.IsValid() {
+			return field, true
+		}
+	}
+	return
+}
+
+// inPackages reports if pkgPath is any of the packages, or any of
+// them vendored (e.g. example.com/app/vendor/github.com/lib/pq)
+func inPackages(pkgPath string, pkgPaths []string) bool {
+	for _, path := range pkgPaths {
+		if pkgPath == path || strings.HasSuffix(pkgPath, "/vendor/"+path) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSQLiteBusy reports if err is a SQLITE_BUSY error of
+// github.com/mattn/go-sqlite3
+func IsSQLiteBusy(err error) bool {
+	field, ok := errorField(err, "Code", "github.com/mattn/go-sqlite3")
+	if !ok {
+		return false
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return field.Int() == 5
+	}
+	return false
+}
+
+// sqlStater is implemented by the errors of github.com/jackc/pgconn
+// and recent github.com/lib/pq
+type sqlStater interface {
+	SQLState() string
+}
+
+// IsPostgresRetryable reports if err is a serialization failure (40001)
+// or deadlock detected (40P01) error of github.com/lib/pq or
+// github.com/jackc/pgconn
+func IsPostgresRetryable(err error) bool {
+	var code string
+	if stater := sqlStater(nil); errors.As(err, &stater) {
+		code = stater.SQLState()
+	} else if field, ok := errorField(err, "Code", "github.com/lib/pq"); ok && field.Kind() == reflect.String {
+		code = field.String()
+	}
+	return code == "40001" || code == "40P01"
+}
+
+// IsMySQLDeadlock reports if err is a deadlock (1213) error of
+// github.com/go-sql-driver/mysql
+func IsMySQLDeadlock(err error) bool {
+	field, ok := errorField(err, "Number", "github.com/go-sql-driver/mysql")
+	if !ok {
+		return false
+	}
+	switch field.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return field.Uint() == 1213
+	}
+	return false
+}
+
+// IsRetryable is the default Retryable, which reports if err is
+// retryable by any of the built-in classifiers
+func IsRetryable(err error) bool {
+	return IsSQLiteBusy(err) || IsPostgresRetryable(err) || IsMySQLDeadlock(err)
+}
+
+// NoRetries is the RetryOptions.MaxRetries to run the transaction
+// only once
+const NoRetries = -1
+
+// RetryOptions configures RetryTransaction
+type RetryOptions struct {
+	// TxOptions, if not nil, is used to begin the transaction
+	// (e.g. to set the isolation level to sql.LevelSerializable)
+	TxOptions *sql.TxOptions
+
+	// MaxRetries is the number of retries after the first attempt.
+	// Zero defaults to 3, and a negative number (e.g. NoRetries)
+	// disables retries.
+	MaxRetries int
+
+	// Backoff is the wait before the first retry, which doubles
+	// for each subsequent retry. Defaults to 10ms.
+	Backoff time.Duration
+
+	// MaxBackoff caps the wait between retries. Zero means no cap.
+	MaxBackoff time.Duration
+
+	// Retryable classifies the errors. Defaults to IsRetryable.
+	Retryable Retryable
+}
+
+// RetryTransaction works like Transaction, but runs fn again in a new
+// transaction if the transaction fails with a retryable error. Each
+// retry is logged as warning with the logger of logcontext.
+//
+// If ctx is already in a transaction, fn runs only once in a SAVEPOINT,
+// as the outer transaction would have failed anyway.
+func RetryTransaction(ctx context.Context, opts RetryOptions, fn func(ctx context.Context) error) (err error) {
+	if GetTx(ctx) != nil {
+		return Transaction(ctx, fn)
+	}
+
+	switch {
+	case opts.MaxRetries == 0:
+		opts.MaxRetries = 3
+	case opts.MaxRetries < 0:
+		opts.MaxRetries = 0
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 10 * time.Millisecond
+	}
+	if opts.Retryable == nil {
+		opts.Retryable = IsRetryable
+	}
+
+	backoff := opts.Backoff
+	for attempt := 1; ; attempt++ {
+		err = transaction(ctx, opts.TxOptions, fn)
+		if err == nil || attempt > opts.MaxRetries || !opts.Retryable(err) {
+			return
+		}
+
+		logcontext.GetLogger(ctx).Log(
+			"at", "warning",
+			"msg", "retry transaction",
+			"attempt", attempt,
+			"backoff", backoff,
+			"err", err.Error(),
+		)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if backoff *= 2; opts.MaxBackoff > 0 && backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package gormcontext
+
+import "testing"
+
+func TestInPackages(t *testing.T) {
+	pkgPaths := []string{"github.com/lib/pq", "github.com/go-sql-driver/mysql"}
+	tests := []struct {
+		pkgPath string
+		want    bool
+	}{
+		{"github.com/lib/pq", true},
+		{"github.com/go-sql-driver/mysql", true},
+		{"example.com/app/vendor/github.com/lib/pq", true},
+		{"github.com/lib/pq/oid", false},
+		{"example.com/github.com/lib/pq", false},
+		{"example.com/app", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if want, have := test.want, inPackages(test.pkgPath, pkgPaths); want != have {
+			t.Errorf("test %#v: expected %#v, got %#v", test.pkgPath, want, have)
+		}
+	}
+}
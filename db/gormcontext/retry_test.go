@@ -0,0 +1,236 @@
+package gormcontext_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-midway/midway/db/gormcontext"
+	"github.com/go-midway/midway/logcontext"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// appError is an application error with the fields of driver errors
+type appError struct {
+	Code   string
+	Number uint16
+}
+
+func (err *appError) Error() string {
+	return "app error " + err.Code
+}
+
+// appBusyError is an application error with the field of sqlite3.Error
+type appBusyError struct {
+	Code int
+}
+
+func (err appBusyError) Error() string {
+	return fmt.Sprintf("app error %d", err.Code)
+}
+
+// stateError is an error of SQLSTATE, as pgconn.PgError
+type stateError struct {
+	code string
+}
+
+func (err *stateError) Error() string {
+	return "state " + err.code
+}
+
+func (err *stateError) SQLState() string {
+	return err.code
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "sqlite busy",
+			err:  sqlite3.Error{Code: sqlite3.ErrBusy},
+			want: true,
+		},
+		{
+			name: "sqlite constraint",
+			err:  sqlite3.Error{Code: sqlite3.ErrConstraint},
+			want: false,
+		},
+		{
+			name: "postgres serialization failure",
+			err:  &pq.Error{Code: "40001"},
+			want: true,
+		},
+		{
+			name: "postgres deadlock detected",
+			err:  &pq.Error{Code: "40P01"},
+			want: true,
+		},
+		{
+			name: "postgres unique violation",
+			err:  &pq.Error{Code: "23505"},
+			want: false,
+		},
+		{
+			name: "postgres serialization failure of SQLState",
+			err:  fmt.Errorf("some query: %w", &stateError{code: "40001"}),
+			want: true,
+		},
+		{
+			name: "postgres unique violation of SQLState",
+			err:  &stateError{code: "23505"},
+			want: false,
+		},
+		{
+			name: "mysql deadlock",
+			err:  &mysql.MySQLError{Number: 1213},
+			want: true,
+		},
+		{
+			name: "mysql duplicate entry",
+			err:  &mysql.MySQLError{Number: 1062},
+			want: false,
+		},
+		{
+			name: "wrapped mysql deadlock",
+			err:  fmt.Errorf("some query: %w", &mysql.MySQLError{Number: 1213}),
+			want: true,
+		},
+		{
+			name: "application error of postgres code",
+			err:  &appError{Code: "40001"},
+			want: false,
+		},
+		{
+			name: "application error of mysql number",
+			err:  &appError{Number: 1213},
+			want: false,
+		},
+		{
+			name: "application error of sqlite code",
+			err:  appBusyError{Code: 5},
+			want: false,
+		},
+		{
+			name: "plain error",
+			err:  fmt.Errorf("some error"),
+			want: false,
+		},
+		{
+			name: "nil",
+			err:  nil,
+			want: false,
+		},
+	}
+	for _, test := range tests {
+		if want, have := test.want, gormcontext.IsRetryable(test.err); want != have {
+			t.Errorf("test %#v: expected %#v, got %#v", test.name, want, have)
+		}
+	}
+}
+
+func TestRetryTransaction(t *testing.T) {
+	db := openTxDB(t)
+	buf := &bytes.Buffer{}
+	ctx := gormcontext.WithDB(context.Background(), db)
+	ctx = logcontext.WithLogger(ctx, kitlog.NewLogfmtLogger(buf))
+
+	attempts := 0
+	err := gormcontext.RetryTransaction(ctx, gormcontext.RetryOptions{
+		Backoff: time.Millisecond,
+	}, func(ctx context.Context) error {
+		attempts++
+		gormcontext.GetDB(ctx).Create(&txItem{ID: attempts})
+		if attempts < 3 {
+			return &pq.Error{Code: "40001", Message: "could not serialize access"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := 3, attempts; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "[3]", txItemIDs(db); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	logs := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if want, have := 2, len(logs); want != have {
+		t.Fatalf("expected %#v, got %#v", want, have)
+	}
+	if want, have := `at=warning msg="retry transaction" attempt=2 backoff=2ms `, logs[1]; !strings.HasPrefix(have, want) {
+		t.Errorf("expected log to start with %#v, got %#v", want, have)
+	}
+}
+
+func TestRetryTransaction_giveUp(t *testing.T) {
+	db := openTxDB(t)
+	ctx := gormcontext.WithDB(context.Background(), db)
+	ctx = logcontext.WithLogger(ctx, kitlog.NewNopLogger())
+
+	// not retryable
+	attempts := 0
+	err := gormcontext.RetryTransaction(ctx, gormcontext.RetryOptions{}, func(ctx context.Context) error {
+		attempts++
+		return fmt.Errorf("some error")
+	})
+	if want, have := "some error", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 1, attempts; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// retries exhausted
+	attempts = 0
+	err = gormcontext.RetryTransaction(ctx, gormcontext.RetryOptions{
+		MaxRetries: 2,
+		Backoff:    time.Millisecond,
+		Retryable: func(err error) bool {
+			return true
+		},
+	}, func(ctx context.Context) error {
+		attempts++
+		return fmt.Errorf("attempt %d", attempts)
+	})
+	if want, have := "attempt 3", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 3, attempts; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// retries disabled
+	attempts = 0
+	gormcontext.RetryTransaction(ctx, gormcontext.RetryOptions{
+		MaxRetries: gormcontext.NoRetries,
+	}, func(ctx context.Context) error {
+		attempts++
+		return &mysql.MySQLError{Number: 1213}
+	})
+	if want, have := 1, attempts; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// nested in transaction runs only once
+	attempts = 0
+	gormcontext.Transaction(ctx, func(ctx context.Context) error {
+		return gormcontext.RetryTransaction(ctx, gormcontext.RetryOptions{}, func(ctx context.Context) error {
+			attempts++
+			return &mysql.MySQLError{Number: 1213}
+		})
+	})
+	if want, have := 1, attempts; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
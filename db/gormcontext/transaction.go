@@ -0,0 +1,100 @@
+package gormcontext
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrNoDB is returned by Transaction if the context have no *gorm.DB
+var ErrNoDB = errors.New("gormcontext: no *gorm.DB in context")
+
+// txState is the transaction stored in context
+type txState struct {
+	tx    *gorm.DB
+	depth int
+}
+
+func withTx(parent context.Context, state *txState) context.Context {
+	return WithDB(context.WithValue(parent, txCtxKey, state), state.tx)
+}
+
+// GetTx returns the *gorm.DB of the current transaction or nil if
+// the context is not in one
+func GetTx(ctx context.Context) (tx *gorm.DB) {
+	if state, ok := ctx.Value(txCtxKey).(*txState); ok {
+		tx = state.tx
+	}
+	return
+}
+
+// Transaction runs fn in a transaction of the *gorm.DB in ctx. The
+// transaction is committed if fn returns nil, or rolled back if fn
+// returns an error or panics.
+//
+// The context passed to fn carries the transaction, so GetDB returns
+// it. If ctx is already in a transaction, fn runs in a SAVEPOINT of it
+// instead, and only the changes made by fn are rolled back on error.
+func Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return transaction(ctx, nil, fn)
+}
+
+func transaction(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) (err error) {
+	if state, ok := ctx.Value(txCtxKey).(*txState); ok {
+		return savepoint(ctx, state, fn)
+	}
+
+	db := GetDB(ctx)
+	if db == nil {
+		return ErrNoDB
+	}
+
+	tx := db.BeginTx(ctx, opts)
+	if err = tx.Error; err != nil {
+		return
+	}
+
+	panicked := true
+	defer func() {
+		if panicked || err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err = fn(withTx(ctx, &txState{tx: tx}))
+	if err == nil {
+		err = tx.Commit().Error
+	}
+	panicked = false
+	return
+}
+
+func savepoint(ctx context.Context, parent *txState, fn func(ctx context.Context) error) (err error) {
+	state := &txState{
+		tx:    parent.tx,
+		depth: parent.depth + 1,
+	}
+	name := fmt.Sprintf("gormcontext_sp%d", state.depth)
+
+	if err = state.tx.Exec("SAVEPOINT " + name).Error; err != nil {
+		return
+	}
+
+	panicked := true
+	defer func() {
+		if panicked || err != nil {
+			state.tx.Exec("ROLLBACK TO SAVEPOINT " + name)
+			state.tx.Exec("RELEASE SAVEPOINT " + name)
+		}
+	}()
+
+	err = fn(withTx(ctx, state))
+	if err == nil {
+		err = state.tx.Exec("RELEASE SAVEPOINT " + name).Error
+	}
+	panicked = false
+	return
+}
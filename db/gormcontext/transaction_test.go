@@ -0,0 +1,155 @@
+package gormcontext_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-midway/midway/db/gormcontext"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+type txItem struct {
+	ID int
+}
+
+func openTxDB(t *testing.T) *gorm.DB {
+	dir, err := os.MkdirTemp("", "gormcontext")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	db, err := gorm.Open("sqlite3", filepath.Join(dir, "tx.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	t.Cleanup(func() {
+		db.Close()
+		os.RemoveAll(dir)
+	})
+	if err = db.AutoMigrate(&txItem{}).Error; err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	return db
+}
+
+func txItemIDs(db *gorm.DB) string {
+	var items []txItem
+	db.Order("id").Find(&items)
+	ids := make([]int, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+	return fmt.Sprintf("%v", ids)
+}
+
+func TestTransaction_noDB(t *testing.T) {
+	err := gormcontext.Transaction(context.Background(), func(ctx context.Context) error {
+		t.Errorf("unexpected call")
+		return nil
+	})
+	if want, have := gormcontext.ErrNoDB, err; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestTransaction_commitAndRollback(t *testing.T) {
+	db := openTxDB(t)
+	ctx := gormcontext.WithDB(context.Background(), db)
+
+	err := gormcontext.Transaction(ctx, func(ctx context.Context) error {
+		tx := gormcontext.GetDB(ctx)
+		if tx == db {
+			t.Errorf("expected the transaction in context, got the original db")
+		}
+		if want, have := tx, gormcontext.GetTx(ctx); want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+		return tx.Create(&txItem{ID: 1}).Error
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+
+	err = gormcontext.Transaction(ctx, func(ctx context.Context) error {
+		gormcontext.GetDB(ctx).Create(&txItem{ID: 2})
+		return fmt.Errorf("some error")
+	})
+	if want, have := "some error", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	if want, have := "[1]", txItemIDs(db); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if tx := gormcontext.GetTx(ctx); tx != nil {
+		t.Errorf("unexpected value: %#v", tx)
+	}
+}
+
+func TestTransaction_nested(t *testing.T) {
+	db := openTxDB(t)
+	ctx := gormcontext.WithDB(context.Background(), db)
+
+	err := gormcontext.Transaction(ctx, func(ctx context.Context) error {
+		outer := gormcontext.GetDB(ctx)
+		outer.Create(&txItem{ID: 1})
+
+		// rolled back to savepoint
+		err := gormcontext.Transaction(ctx, func(ctx context.Context) error {
+			if want, have := outer, gormcontext.GetDB(ctx); want != have {
+				t.Errorf("expected %#v, got %#v", want, have)
+			}
+			gormcontext.GetDB(ctx).Create(&txItem{ID: 2})
+			return fmt.Errorf("inner error")
+		})
+		if want, have := "inner error", fmt.Sprintf("%v", err); want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+
+		// released savepoint, with another level of nesting
+		return gormcontext.Transaction(ctx, func(ctx context.Context) error {
+			gormcontext.GetDB(ctx).Create(&txItem{ID: 3})
+			gormcontext.Transaction(ctx, func(ctx context.Context) error {
+				gormcontext.GetDB(ctx).Create(&txItem{ID: 4})
+				return fmt.Errorf("inner error")
+			})
+			return gormcontext.Transaction(ctx, func(ctx context.Context) error {
+				return gormcontext.GetDB(ctx).Create(&txItem{ID: 5}).Error
+			})
+		})
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := "[1 3 5]", txItemIDs(db); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestTransaction_panic(t *testing.T) {
+	db := openTxDB(t)
+	ctx := gormcontext.WithDB(context.Background(), db)
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("expected panic, got nil")
+			}
+		}()
+		gormcontext.Transaction(ctx, func(ctx context.Context) error {
+			gormcontext.GetDB(ctx).Create(&txItem{ID: 1})
+			gormcontext.Transaction(ctx, func(ctx context.Context) error {
+				gormcontext.GetDB(ctx).Create(&txItem{ID: 2})
+				panic("some panic")
+			})
+			return nil
+		})
+	}()
+
+	if want, have := "[]", txItemIDs(db); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
@@ -0,0 +1,188 @@
+package funconv
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// binding fills an argument of srcFunc not given by destFunc
+type binding struct {
+	pos    int
+	value  interface{}
+	getter bool
+}
+
+// Bind makes WrapAs fill the argument of srcFunc at pos (zero based)
+// with value, converted into the argument type at wrap time. The
+// arguments not bound are given by destFunc, in order or in ArgOrder.
+func Bind(pos int, value interface{}) Option {
+	return func(c *config) {
+		c.bindings = append(c.bindings, binding{pos: pos, value: value})
+	}
+}
+
+// BindContext makes WrapAs fill the argument of srcFunc at pos (zero based)
+// with the result of getter, a func(context.Context) T, called with the
+// first context.Context argument of destFunc on each call. For example,
+// gormcontext.GetDB or logcontext.GetLogger. T needs to be assignable
+// to the argument type.
+func BindContext(pos int, getter interface{}) Option {
+	return func(c *config) {
+		c.bindings = append(c.bindings, binding{pos: pos, value: getter, getter: true})
+	}
+}
+
+// ArgOrder makes WrapAs give the arguments of srcFunc not bound, in order,
+// from the arguments of destFunc at the positions (zero based) of order.
+// The arguments of destFunc not in order are dropped.
+func ArgOrder(order ...int) Option {
+	return func(c *config) {
+		c.order = order
+	}
+}
+
+// bound reports if the arguments of srcFunc are bound or reordered
+func (c *config) bound() bool {
+	return len(c.bindings) > 0 || c.order != nil
+}
+
+// bind returns a function taking the arguments of destFuncType, which
+// fills the arguments of srcFunc and calls it. The arguments given by
+// destFunc are of the types of srcFunc, to be converted by WrapAs.
+func (c *config) bind(srcFunc reflect.Value, destFuncType reflect.Type) (bound reflect.Value, err error) {
+	srcFuncType := srcFunc.Type()
+	if srcFuncType.IsVariadic() || destFuncType.IsVariadic() {
+		err = &invalidBindingError{msg: "arguments of variadic functions cannot be bound"}
+		return
+	}
+
+	ctxPos := -1
+	for j := 0; j < destFuncType.NumIn(); j++ {
+		if destFuncType.In(j) == contextType {
+			ctxPos = j
+			break
+		}
+	}
+
+	numIn := srcFuncType.NumIn()
+	fills := make([]func(in []reflect.Value) reflect.Value, numIn)
+	m := c.mapper()
+	for _, b := range c.bindings {
+		if b.pos < 0 || b.pos >= numIn {
+			err = &invalidBindingError{msg: fmt.Sprintf(
+				"argument %d, out of range of srcFunc(%d)", b.pos+1, numIn)}
+			return
+		}
+		if fills[b.pos] != nil {
+			err = &invalidBindingError{msg: fmt.Sprintf(
+				"argument %d, bound more than once", b.pos+1)}
+			return
+		}
+		to := srcFuncType.In(b.pos)
+
+		if b.getter {
+			getter := reflect.ValueOf(b.value)
+			if getter.Kind() != reflect.Func || getter.Type().NumIn() != 1 ||
+				getter.Type().In(0) != contextType || getter.Type().NumOut() != 1 {
+				err = &invalidFuncError{msg: fmt.Sprintf(
+					"getter of argument %d needs to be func(context.Context) T, got %T", b.pos+1, b.value)}
+				return
+			}
+			if from := getter.Type().Out(0); !from.AssignableTo(to) {
+				err = &TypeMismatchError{kind: mismatchArgument, pos: b.pos, from: from, to: to}
+				return
+			}
+			if ctxPos < 0 {
+				err = &invalidBindingError{msg: fmt.Sprintf(
+					"argument %d, destFunc has no context.Context argument to get it from", b.pos+1)}
+				return
+			}
+			fills[b.pos] = func(in []reflect.Value) reflect.Value {
+				ctx := in[ctxPos]
+				if ctx.Kind() == reflect.Interface && ctx.IsNil() {
+					ctx = reflect.ValueOf(context.Background())
+				}
+				return getter.Call([]reflect.Value{ctx})[0]
+			}
+			continue
+		}
+
+		value := reflect.Zero(to)
+		if b.value != nil {
+			from := reflect.TypeOf(b.value)
+			mapped := m.mapType(from, to)
+			if !mapped.ok {
+				err = &TypeMismatchError{kind: mismatchArgument, pos: b.pos, from: from, to: to, reason: mapped.reason}
+				return
+			}
+			if value, err = mapped.conv(reflect.ValueOf(b.value)); err != nil {
+				err = &ArgumentError{pos: b.pos, err: err}
+				return
+			}
+		}
+		fills[b.pos] = func([]reflect.Value) reflect.Value {
+			return value
+		}
+	}
+
+	// the other arguments are given by destFunc
+	var rest []int
+	for i := range fills {
+		if fills[i] == nil {
+			rest = append(rest, i)
+		}
+	}
+	order := c.order
+	if order == nil {
+		if want, have := len(rest), destFuncType.NumIn(); want != have {
+			err = &ArityMismatchError{src: want, dest: have}
+			return
+		}
+		order = make([]int, len(rest))
+		for k := range order {
+			order[k] = k
+		}
+	} else if want, have := len(rest), len(order); want != have {
+		err = &invalidBindingError{msg: fmt.Sprintf(
+			"argument order mismatch, srcFunc(%d) not bound != order(%d)", want, have)}
+		return
+	}
+
+	inTypes := make([]reflect.Type, destFuncType.NumIn())
+	for j := range inTypes {
+		inTypes[j] = destFuncType.In(j)
+	}
+	ordered := make([]bool, len(inTypes))
+	for k, i := range rest {
+		j := order[k]
+		if j < 0 || j >= len(inTypes) {
+			err = &invalidBindingError{msg: fmt.Sprintf(
+				"argument order %d, out of range of destFunc(%d)", j, len(inTypes))}
+			return
+		}
+		if ordered[j] {
+			err = &invalidBindingError{msg: fmt.Sprintf(
+				"argument order %d, given more than once", j)}
+			return
+		}
+		ordered[j] = true
+		inTypes[j] = srcFuncType.In(i)
+		fills[i] = func(in []reflect.Value) reflect.Value {
+			return in[j]
+		}
+	}
+
+	outTypes := make([]reflect.Type, srcFuncType.NumOut())
+	for i := range outTypes {
+		outTypes[i] = srcFuncType.Out(i)
+	}
+	bound = reflect.MakeFunc(reflect.FuncOf(inTypes, outTypes, false), func(in []reflect.Value) []reflect.Value {
+		args := make([]reflect.Value, numIn)
+		for i, fill := range fills {
+			args[i] = fill(in)
+		}
+		return srcFunc.Call(args)
+	})
+	return
+}
@@ -0,0 +1,189 @@
+package funconv_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-midway/midway/funconv"
+	"github.com/go-midway/midway/logcontext"
+)
+
+type greetRequest struct {
+	Name string
+}
+
+// greet is a service method taking dependencies before the request
+func greet(ctx context.Context, logger kitlog.Logger, greeting string, req greetRequest) (string, error) {
+	msg := greeting + " " + req.Name
+	logger.Log("msg", msg)
+	return msg, nil
+}
+
+func TestWrap_bind(t *testing.T) {
+	var endpoint func(ctx context.Context, req interface{}) (interface{}, error)
+	err := funconv.WrapAs(greet, &endpoint,
+		funconv.BindContext(1, logcontext.GetLogger),
+		funconv.Bind(2, "hello"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	buf := &bytes.Buffer{}
+	ctx := logcontext.WithLogger(context.Background(), kitlog.NewLogfmtLogger(buf))
+	resp, err := endpoint(ctx, greetRequest{Name: "gopher"})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := "hello gopher", resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "msg=\"hello gopher\"\n", buf.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// argument positions are of destFunc
+	_, err = endpoint(ctx, "gopher")
+	if want, have := "argument 2, string cannot be converted to funconv_test.greetRequest", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_argOrder(t *testing.T) {
+	divide := func(a, b int) int {
+		return a / b
+	}
+
+	var reversed func(b, a int) int
+	err := funconv.WrapAs(divide, &reversed, funconv.ArgOrder(1, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if want, have := 3, reversed(2, 6); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// partial application, dropping the unused argument
+	var half func(ctx context.Context, a interface{}) (int, error)
+	err = funconv.WrapAs(func(a, b int) (int, error) {
+		return a / b, nil
+	}, &half, funconv.Bind(1, 2), funconv.ArgOrder(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	resp, err := half(context.Background(), 10)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := 5, resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_bindErrors(t *testing.T) {
+	var endpoint func(ctx context.Context, req interface{}) (interface{}, error)
+	var noContext func(req interface{}) (interface{}, error)
+
+	tests := []struct {
+		name string
+		dest interface{}
+		opts []funconv.Option
+		want string
+		is   error
+	}{
+		{
+			name: "arity",
+			dest: &endpoint,
+			opts: []funconv.Option{funconv.Bind(2, "hello")},
+			want: "argument mismatch, srcFunc(3) != destFunc(2)",
+			is:   funconv.ErrArityMismatch,
+		},
+		{
+			name: "out of range",
+			dest: &endpoint,
+			opts: []funconv.Option{funconv.Bind(4, "hello")},
+			want: "argument 5, out of range of srcFunc(4)",
+			is:   funconv.ErrInvalidBinding,
+		},
+		{
+			name: "bound twice",
+			dest: &endpoint,
+			opts: []funconv.Option{funconv.Bind(2, "hello"), funconv.Bind(2, "hi")},
+			want: "argument 3, bound more than once",
+			is:   funconv.ErrInvalidBinding,
+		},
+		{
+			name: "constant type",
+			dest: &endpoint,
+			opts: []funconv.Option{funconv.BindContext(1, logcontext.GetLogger), funconv.Bind(2, 123)},
+			want: "argument 3, int cannot be converted string",
+			is:   funconv.ErrTypeMismatch,
+		},
+		{
+			name: "getter",
+			dest: &endpoint,
+			opts: []funconv.Option{funconv.BindContext(1, "hello"), funconv.Bind(2, "hello")},
+			want: "getter of argument 2 needs to be func(context.Context) T, got string",
+			is:   funconv.ErrInvalidFunc,
+		},
+		{
+			name: "getter type",
+			dest: &endpoint,
+			opts: []funconv.Option{funconv.BindContext(1, func(ctx context.Context) string { return "" }), funconv.Bind(2, "hello")},
+			want: "argument 2, string cannot be converted log.Logger",
+			is:   funconv.ErrTypeMismatch,
+		},
+		{
+			name: "no context",
+			dest: &noContext,
+			opts: []funconv.Option{funconv.BindContext(1, logcontext.GetLogger), funconv.Bind(2, "hello"), funconv.ArgOrder(0, 0)},
+			want: "argument 2, destFunc has no context.Context argument to get it from",
+			is:   funconv.ErrInvalidBinding,
+		},
+		{
+			name: "order length",
+			dest: &endpoint,
+			opts: []funconv.Option{funconv.BindContext(1, logcontext.GetLogger), funconv.Bind(2, "hello"), funconv.ArgOrder(0)},
+			want: "argument order mismatch, srcFunc(2) not bound != order(1)",
+			is:   funconv.ErrInvalidBinding,
+		},
+		{
+			name: "order range",
+			dest: &endpoint,
+			opts: []funconv.Option{funconv.BindContext(1, logcontext.GetLogger), funconv.Bind(2, "hello"), funconv.ArgOrder(0, 2)},
+			want: "argument order 2, out of range of destFunc(2)",
+			is:   funconv.ErrInvalidBinding,
+		},
+		{
+			name: "order twice",
+			dest: &endpoint,
+			opts: []funconv.Option{funconv.BindContext(1, logcontext.GetLogger), funconv.Bind(2, "hello"), funconv.ArgOrder(1, 1)},
+			want: "argument order 1, given more than once",
+			is:   funconv.ErrInvalidBinding,
+		},
+	}
+	for _, test := range tests {
+		err := funconv.WrapAs(greet, test.dest, test.opts...)
+		if err == nil {
+			t.Errorf("%s: expected error, got nil", test.name)
+		} else if want, have := test.want, err.Error(); want != have {
+			t.Errorf("%s: expected %#v, got %#v", test.name, want, have)
+		}
+		if !errors.Is(err, test.is) {
+			t.Errorf("%s: expected errors.Is(err, %#v), got %#v", test.name, test.is.Error(), err)
+		}
+	}
+
+	// variadic functions
+	var variadic func(ctx context.Context, names ...string) (interface{}, error)
+	err := funconv.WrapAs(func(ctx context.Context, greeting string, names ...string) error {
+		return nil
+	}, &variadic, funconv.Bind(1, "hello"))
+	if !errors.Is(err, funconv.ErrInvalidBinding) {
+		t.Errorf("expected ErrInvalidBinding, got %#v", err)
+	}
+}
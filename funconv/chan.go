@@ -0,0 +1,120 @@
+package funconv
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// ElementError is the error of converting a value received from the
+// source channel of ConvertChan
+type ElementError struct {
+	pos int
+	err error
+}
+
+// Position returns the position (zero based) of the value in the
+// values received from the source channel
+func (err ElementError) Position() int {
+	return err.pos
+}
+
+func (err ElementError) Error() string {
+	return fmt.Sprintf("element %d, %s", err.pos+1, err.err.Error())
+}
+
+// Unwrap returns the error of the conversion
+func (err ElementError) Unwrap() error {
+	return err.err
+}
+
+// ConvertChan returns a channel of type to, which receives the values
+// received from src, a channel, converted into the element type of to.
+// The values are forwarded by a goroutine, and the channel returned
+// has the same buffer size as src.
+//
+// Nil interfaces are converted to the zero value. The values failed to
+// convert, or panicked in converting, are skipped, and their errors are
+// sent to errs as *ElementError. Both dest and errs need to be received
+// from, or the forwarding blocks until ctx is done.
+//
+// When src is closed, or ctx is done, the goroutine closes dest and errs
+// and returns. The values of src not yet received are left in it.
+//
+// Channels are not converted by WrapAs or MapConverter, which have no
+// context to stop the forwarding or channel to send the errors to.
+func ConvertChan(ctx context.Context, src interface{}, to reflect.Type, opts ...Option) (dest interface{}, errs <-chan error, err error) {
+	srcVal := reflect.ValueOf(src)
+	if srcVal.Kind() != reflect.Chan || srcVal.Type().ChanDir()&reflect.RecvDir == 0 {
+		err = fmt.Errorf("src needs to be a channel to receive from, got %T", src)
+		return
+	}
+	if srcVal.IsNil() {
+		err = fmt.Errorf("src cannot be nil")
+		return
+	}
+	if to == nil || to.Kind() != reflect.Chan || to.ChanDir()&reflect.RecvDir == 0 {
+		err = fmt.Errorf("to needs to be a channel type to receive from, got %v", to)
+		return
+	}
+
+	from := srcVal.Type()
+	mapped := mapperOf(opts).mapType(from.Elem(), to.Elem())
+	if !mapped.ok {
+		err = &TypeMismatchError{from: from.Elem(), to: to.Elem(), reason: mapped.reason}
+		return
+	}
+
+	destVal := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, to.Elem()), srcVal.Cap())
+	errCh := make(chan error)
+	go forward(ctx, srcVal, destVal, errCh, zeroNil(mapped.conv, to.Elem()))
+	return destVal.Convert(to).Interface(), errCh, nil
+}
+
+// forward sends the values received from src to dest, converted with
+// conv, until src is closed or ctx is done. Then it closes dest and errs.
+func forward(ctx context.Context, src, dest reflect.Value, errs chan<- error, conv Converter) {
+	defer close(errs)
+	defer dest.Close()
+
+	done := reflect.ValueOf(ctx.Done())
+	recvCases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: done},
+		{Dir: reflect.SelectRecv, Chan: src},
+	}
+	for pos := 0; ; pos++ {
+		chosen, value, ok := reflect.Select(recvCases)
+		if chosen == 0 || !ok {
+			return
+		}
+
+		converted, err := convertElem(conv, value)
+		if err != nil {
+			select {
+			case errs <- &ElementError{pos: pos, err: err}:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		sendCases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: done},
+			{Dir: reflect.SelectSend, Chan: dest, Send: converted},
+		}
+		if chosen, _, _ := reflect.Select(sendCases); chosen == 0 {
+			return
+		}
+	}
+}
+
+// convertElem converts value with conv, returning its panics as
+// *PanicError
+func convertElem(conv Converter, value reflect.Value) (converted reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = newPanicError(r)
+		}
+	}()
+	return conv(value)
+}
@@ -0,0 +1,340 @@
+package funconv_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-midway/midway/funconv"
+)
+
+type event struct {
+	ID   int
+	Name string
+}
+
+// collect receives from dest and errs until both are closed
+func collect(dest <-chan interface{}, errs <-chan error) (values []interface{}, errMsgs []string) {
+	for dest != nil || errs != nil {
+		select {
+		case v, ok := <-dest:
+			if !ok {
+				dest = nil
+				continue
+			}
+			values = append(values, v)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+	return
+}
+
+func TestConvertChan(t *testing.T) {
+	src := make(chan event)
+	go func() {
+		defer close(src)
+		for i := 1; i <= 3; i++ {
+			src <- event{ID: i, Name: fmt.Sprintf("event %d", i)}
+		}
+	}()
+
+	dest, errs, err := funconv.ConvertChan(context.Background(), (<-chan event)(src),
+		reflect.TypeOf((<-chan interface{})(nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	values, errMsgs := collect(dest.(<-chan interface{}), errs)
+	if want, have := 3, len(values); want != have {
+		t.Fatalf("expected %#v, got %#v", want, have)
+	}
+	for i, v := range values {
+		if want, have := (event{ID: i + 1, Name: fmt.Sprintf("event %d", i+1)}), v; want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+	}
+	if want, have := 0, len(errMsgs); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestConvertChan_errors(t *testing.T) {
+	src := make(chan interface{}, 4)
+	src <- event{ID: 1}
+	src <- "not an event"
+	src <- event{ID: 2}
+	close(src)
+
+	converted, errs, err := funconv.ConvertChan(context.Background(), src, reflect.TypeOf((<-chan event)(nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	dest := converted.(<-chan event)
+
+	var events []event
+	var convErrs []error
+	for dest != nil || errs != nil {
+		select {
+		case e, ok := <-dest:
+			if !ok {
+				dest = nil
+				continue
+			}
+			events = append(events, e)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			convErrs = append(convErrs, err)
+		}
+	}
+	if want, have := 2, len(events); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 1, len(convErrs); want != have {
+		t.Fatalf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "element 2, string cannot be converted to funconv_test.event", convErrs[0].Error(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	var elemErr *funconv.ElementError
+	if !errors.As(convErrs[0], &elemErr) {
+		t.Errorf("expected *funconv.ElementError, got %#v", convErrs[0])
+	} else if want, have := 1, elemErr.Position(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestConvertChan_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := make(chan int)
+
+	// the producer stops when the context is done
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case src <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	converted, errs, err := funconv.ConvertChan(ctx, src, reflect.TypeOf((<-chan int64)(nil)),
+		funconv.WithRules(funconv.CheckedNumbers))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	dest := converted.(<-chan int64)
+	for i := int64(0); i < 3; i++ {
+		if want, have := i, <-dest; want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+	}
+	cancel()
+
+	// dest and errs are closed after cancellation, even if
+	// src is never closed
+	timeout := time.After(time.Second)
+	for dest != nil || errs != nil {
+		select {
+		case _, ok := <-dest:
+			if !ok {
+				dest = nil
+			}
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+			}
+		case <-timeout:
+			t.Fatalf("expected channels to be closed")
+		}
+	}
+	wg.Wait()
+}
+
+func TestConvertChan_concurrent(t *testing.T) {
+	src := make(chan int, 8)
+	var wg sync.WaitGroup
+	for p := 0; p < 4; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				src <- p*100 + i
+			}
+		}(p)
+	}
+	go func() {
+		wg.Wait()
+		close(src)
+	}()
+
+	dest, errs, err := funconv.ConvertChan(context.Background(), src, reflect.TypeOf((chan interface{})(nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	// consumers receive concurrently
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	var consumers sync.WaitGroup
+	for c := 0; c < 4; c++ {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			for v := range dest.(chan interface{}) {
+				mu.Lock()
+				seen[v.(int)] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	for err := range errs {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	consumers.Wait()
+	if want, have := 400, len(seen); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestConvertChan_invalid(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		src  interface{}
+		to   reflect.Type
+		want string
+	}{
+		{
+			name: "not a channel",
+			src:  []int{1},
+			to:   reflect.TypeOf((<-chan int)(nil)),
+			want: "src needs to be a channel to receive from, got []int",
+		},
+		{
+			name: "send only",
+			src:  make(chan<- int),
+			to:   reflect.TypeOf((<-chan int)(nil)),
+			want: "src needs to be a channel to receive from, got chan<- int",
+		},
+		{
+			name: "nil",
+			src:  (chan int)(nil),
+			to:   reflect.TypeOf((<-chan int)(nil)),
+			want: "src cannot be nil",
+		},
+		{
+			name: "to",
+			src:  make(chan int),
+			to:   reflect.TypeOf((chan<- int)(nil)),
+			want: "to needs to be a channel type to receive from, got chan<- int",
+		},
+		{
+			name: "element type",
+			src:  make(chan int),
+			to:   reflect.TypeOf((<-chan string)(nil)),
+			want: "at 0, int cannot be converted to string",
+		},
+	}
+	for _, test := range tests {
+		_, _, err := funconv.ConvertChan(ctx, test.src, test.to)
+		if err == nil {
+			t.Errorf("%s: expected error, got nil", test.name)
+		} else if want, have := test.want, err.Error(); want != have {
+			t.Errorf("%s: expected %#v, got %#v", test.name, want, have)
+		}
+	}
+}
+
+// receiveAll receives from dest and errs until both are closed
+func receiveAll(dest <-chan string, errs <-chan error) (values []string, convErrs []error) {
+	for dest != nil || errs != nil {
+		select {
+		case v, ok := <-dest:
+			if !ok {
+				dest = nil
+				continue
+			}
+			values = append(values, v)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			convErrs = append(convErrs, err)
+		}
+	}
+	return
+}
+
+func TestConvertChan_nil(t *testing.T) {
+	src := make(chan interface{}, 2)
+	src <- nil
+	src <- "a"
+	close(src)
+
+	converted, errs, err := funconv.ConvertChan(context.Background(), src, reflect.TypeOf((<-chan string)(nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	values, convErrs := receiveAll(converted.(<-chan string), errs)
+	if want, have := `["" "a"]`, fmt.Sprintf("%q", values); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 0, len(convErrs); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestConvertChan_panics(t *testing.T) {
+	src := make(chan int, 2)
+	src <- 1
+	src <- 2
+	close(src)
+
+	// the rule panics on odd numbers
+	oddPanics := func(from, to reflect.Type) funconv.Converter {
+		if from.Kind() != reflect.Int || to.Kind() != reflect.String {
+			return nil
+		}
+		return func(src reflect.Value) (reflect.Value, error) {
+			if src.Int()%2 == 1 {
+				panic("odd number")
+			}
+			return reflect.ValueOf(fmt.Sprint(src.Int())), nil
+		}
+	}
+	converted, errs, err := funconv.ConvertChan(context.Background(), src,
+		reflect.TypeOf((<-chan string)(nil)), funconv.WithRules(oddPanics))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	values, convErrs := receiveAll(converted.(<-chan string), errs)
+	if want, have := `["2"]`, fmt.Sprintf("%q", values); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 1, len(convErrs); want != have {
+		t.Fatalf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "element 1, panic: odd number", convErrs[0].Error(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	var panicErr *funconv.PanicError
+	if !errors.As(convErrs[0], &panicErr) {
+		t.Errorf("expected *funconv.PanicError, got %#v", convErrs[0])
+	}
+}
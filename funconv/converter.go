@@ -0,0 +1,267 @@
+package funconv
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Converter converts one reflect.Value into another
+type Converter func(reflect.Value) (reflect.Value, error)
+
+// Convert the src to dest, according to the reflect.Value conversion
+func (conv Converter) Convert(src interface{}) interface{} {
+	srcValue := reflect.ValueOf(src)
+	destValue, err := conv(srcValue)
+	if err != nil {
+		panic(err)
+	}
+	return destValue.Interface()
+}
+
+func directMap(src reflect.Value) (reflect.Value, error) {
+	return src, nil
+}
+
+func convertToType(typ reflect.Type) Converter {
+	return func(src reflect.Value) (reflect.Value, error) {
+		return src.Convert(typ), nil
+	}
+}
+
+// nillable reports if nil is a value of typ
+func nillable(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return true
+	}
+	return false
+}
+
+func reverseConvertToItfType(typ reflect.Type) Converter {
+	return func(src reflect.Value) (dest reflect.Value, err error) {
+
+		srcInner := src
+		if src.Kind() == reflect.Interface {
+			// nil interface is only converted to the types of nil
+			if src.IsNil() {
+				if nillable(typ) {
+					return reflect.Zero(typ), nil
+				}
+				err = fmt.Errorf("<nil> cannot be converted to %s", typ.String())
+				return
+			}
+			srcInner = src.Elem()
+		}
+
+		if srcInner.Type() == typ {
+			dest = srcInner
+			return
+		}
+		if srcInner.Type().AssignableTo(typ) {
+			dest = srcInner.Convert(typ)
+			return
+		}
+
+		// TODO: return as error
+		err = fmt.Errorf("%s cannot be converted to %s",
+			srcInner.Type().String(), typ.String())
+		return
+	}
+}
+
+// typePair is the key of the cache of mapper
+type typePair struct {
+	from reflect.Type
+	to   reflect.Type
+}
+
+// mappedConverter is the cached result of mapping a typePair
+type mappedConverter struct {
+	conv Converter
+
+	// direct is true if the value can be passed as is to reflect.Value.Call,
+	// or returned as is by a reflect.MakeFunc function, which assign
+	// values to their variable types
+	direct bool
+
+	ok bool
+
+	// reason explains why the types cannot be converted, if known
+	reason error
+}
+
+// mapper maps and caches the converters between types
+type mapper struct {
+	// registered are consulted before the built-in rules
+	registered []Rule
+
+	// rules are consulted before the rules between containers
+	rules []Rule
+
+	cache sync.Map
+	plans sync.Map
+
+	// mu serializes the mapping of uncached types, and guards building
+	// and pending
+	mu sync.Mutex
+
+	// building holds placeholders of the converters being mapped,
+	// so recursive types can refer to their own converters
+	building map[typePair]*Converter
+
+	// pending holds the converters mapped while mapping the outermost
+	// types, to be cached if the outermost types are mapped
+	pending map[typePair]mappedConverter
+}
+
+func newMapper(registered, rules []Rule) *mapper {
+	return &mapper{
+		registered: registered,
+		rules:      rules,
+		building:   make(map[typePair]*Converter),
+		pending:    make(map[typePair]mappedConverter),
+	}
+}
+
+// identicalMapped is the mappedConverter of identical types
+var identicalMapped = mappedConverter{conv: directMap, direct: true, ok: true}
+
+// mapBuiltin maps assignable types, or interface types into the types
+// implementing them, which are cheaper to map than to cache
+func mapBuiltin(from, to reflect.Type) (mapped mappedConverter) {
+	switch {
+	case from.AssignableTo(to):
+		mapped = mappedConverter{conv: convertToType(to), direct: true, ok: true}
+	case from.Kind() == reflect.Interface && to.Implements(from):
+		mapped = mappedConverter{conv: reverseConvertToItfType(to), ok: true}
+	}
+	return
+}
+
+// mapType finds the converter of a value of type from into type to.
+// Identical types, and the built-in types unless rules are registered,
+// are mapped without the cache.
+func (m *mapper) mapType(from, to reflect.Type) mappedConverter {
+	if from == to {
+		return identicalMapped
+	}
+	if len(m.registered) == 0 {
+		if mapped := mapBuiltin(from, to); mapped.ok {
+			return mapped
+		}
+	}
+	if cached, ok := m.cache.Load(typePair{from: from, to: to}); ok {
+		return cached.(mappedConverter)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.build(from, to)
+}
+
+// build maps the converter with m.mu held
+func (m *mapper) build(from, to reflect.Type) (mapped mappedConverter) {
+	key := typePair{from: from, to: to}
+	if cached, ok := m.cache.Load(key); ok {
+		return cached.(mappedConverter)
+	}
+	if pending, ok := m.pending[key]; ok {
+		return pending
+	}
+
+	// recursive type, use the converter when it is ready
+	if placeholder, ok := m.building[key]; ok {
+		return mappedConverter{
+			conv: func(src reflect.Value) (reflect.Value, error) {
+				return (*placeholder)(src)
+			},
+			ok: true,
+		}
+	}
+	placeholder := new(Converter)
+	m.building[key] = placeholder
+
+	mapped = m.mapTypes(from, to)
+	*placeholder = mapped.conv
+	delete(m.building, key)
+	m.pending[key] = mapped
+
+	// the converters mapped meanwhile may refer to the placeholders of
+	// the types failed to map, so they are cached only if the outermost
+	// types are mapped
+	if len(m.building) == 0 {
+		if mapped.ok {
+			for pendingKey, pending := range m.pending {
+				m.cache.Store(pendingKey, pending)
+			}
+		}
+		clear(m.pending)
+	}
+	return
+}
+
+// mapTypes consults the registered rules, then the built-in rules
+func (m *mapper) mapTypes(from, to reflect.Type) mappedConverter {
+	if from == to {
+		return identicalMapped
+	}
+	for _, rule := range m.registered {
+		if conv := rule(from, to); conv != nil {
+			return mappedConverter{conv: conv, ok: true}
+		}
+	}
+
+	if mapped := mapBuiltin(from, to); mapped.ok {
+		return mapped
+	}
+	return m.mapRules(from, to)
+}
+
+// mapRules maps the converter with m.rules, then the rules between
+// containers, structs or functions
+func (m *mapper) mapRules(from, to reflect.Type) (mapped mappedConverter) {
+	for _, rule := range m.rules {
+		if conv := rule(from, to); conv != nil {
+			return mappedConverter{conv: conv, ok: true}
+		}
+	}
+
+	switch {
+	case from.Kind() == reflect.Ptr || to.Kind() == reflect.Ptr:
+		mapped = m.mapPtr(from, to)
+	case isList(from) && isList(to):
+		mapped = m.mapList(from, to)
+	case from.Kind() == reflect.Map && to.Kind() == reflect.Map:
+		mapped = m.mapMap(from, to)
+	case from.Kind() == reflect.Struct && to.Kind() == reflect.Struct:
+		mapped = m.mapStruct(from, to)
+	case from.Kind() == reflect.Func && to.Kind() == reflect.Func:
+		mapped = m.mapFunc(from, to)
+	}
+	return
+}
+
+// MapConverter maps converter slice for converting variable inTypes into outTypes
+func MapConverter(inTypes []reflect.Type, outTypes []reflect.Type, opts ...Option) (converters []Converter, err error) {
+	m := mapperOf(opts)
+	length := len(inTypes)
+	converters = make([]Converter, length)
+	var errs TypeMismatchErrors
+	for i := 0; i < length; i++ {
+		mapped := m.mapType(inTypes[i], outTypes[i])
+		if mapped.ok {
+			converters[i] = mapped.conv
+			continue
+		}
+		errs = append(errs, &TypeMismatchError{
+			pos:    i,
+			from:   inTypes[i],
+			to:     outTypes[i],
+			reason: mapped.reason,
+		})
+	}
+	if len(errs) > 0 {
+		err = errs
+	}
+	return
+}
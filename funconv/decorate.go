@@ -0,0 +1,62 @@
+package funconv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Invoker calls a function with the arguments. The variadic arguments
+// are given as a slice.
+type Invoker func(args []reflect.Value) (results []reflect.Value)
+
+// Decorator decorates the Invoker of a function of fnType. It is called
+// once by Decorate, so it may inspect fnType beforehand.
+type Decorator func(fnType reflect.Type, next Invoker) Invoker
+
+// Decorate returns a function of the same type as fn, which calls fn
+// through the decorators. Like midway.Chain, the first decorator is
+// the outermost.
+func Decorate(fn interface{}, decorators ...Decorator) (decorated interface{}, err error) {
+	fnVal := reflect.ValueOf(fn)
+	if fnVal.Kind() != reflect.Func {
+		err = &invalidFuncError{msg: fmt.Sprintf("fn needs to be a function, got %T", fn)}
+		return
+	}
+
+	fnType := fnVal.Type()
+	invoke := fnVal.Call
+	if fnType.IsVariadic() {
+		invoke = fnVal.CallSlice
+	}
+	for i := len(decorators) - 1; i >= 0; i-- {
+		invoke = decorators[i](fnType, invoke)
+	}
+	decorated = reflect.MakeFunc(fnType, invoke).Interface()
+	return
+}
+
+// ResultError returns the error of the results of a function returning
+// error as the last variable, or nil
+func ResultError(results []reflect.Value) error {
+	n := len(results)
+	if n == 0 || results[n-1].Type() != errorType || results[n-1].IsNil() {
+		return nil
+	}
+	return results[n-1].Interface().(error)
+}
+
+// ErrorResults returns the results of a function of fnType failed with
+// err, which are zero values but err as the last variable. It panics if
+// the function does not return error as the last variable.
+func ErrorResults(fnType reflect.Type, err error) []reflect.Value {
+	n := fnType.NumOut()
+	if n == 0 || fnType.Out(n-1) != errorType {
+		panic(fmt.Sprintf("funconv: %s does not return error", fnType.String()))
+	}
+	results := make([]reflect.Value, n)
+	for i := 0; i < n-1; i++ {
+		results[i] = reflect.Zero(fnType.Out(i))
+	}
+	results[n-1] = reflect.ValueOf(&err).Elem()
+	return results
+}
@@ -0,0 +1,189 @@
+package funconv_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-midway/midway/funconv"
+)
+
+// interfaces returns the values as interface{}
+func interfaces(values []reflect.Value) []interface{} {
+	itfs := make([]interface{}, len(values))
+	for i, value := range values {
+		itfs[i] = value.Interface()
+	}
+	return itfs
+}
+
+// logCalls is a decorator logging the arguments and results of calls
+func logCalls(logs *[]string) funconv.Decorator {
+	return func(fnType reflect.Type, next funconv.Invoker) funconv.Invoker {
+		return func(args []reflect.Value) []reflect.Value {
+			results := next(args)
+			*logs = append(*logs, fmt.Sprintf("%s%v -> %v",
+				fnType.String(), interfaces(args), interfaces(results)))
+			return results
+		}
+	}
+}
+
+// retry is a decorator calling again, up to max times, on error
+func retry(max int) funconv.Decorator {
+	return func(fnType reflect.Type, next funconv.Invoker) funconv.Invoker {
+		return func(args []reflect.Value) (results []reflect.Value) {
+			for attempt := 0; attempt <= max; attempt++ {
+				if results = next(args); funconv.ResultError(results) == nil {
+					return
+				}
+			}
+			return
+		}
+	}
+}
+
+// recoverPanics is a decorator returning panics as errors
+func recoverPanics(fnType reflect.Type, next funconv.Invoker) funconv.Invoker {
+	return func(args []reflect.Value) (results []reflect.Value) {
+		defer func() {
+			if r := recover(); r != nil {
+				results = funconv.ErrorResults(fnType, fmt.Errorf("panic: %v", r))
+			}
+		}()
+		return next(args)
+	}
+}
+
+func TestDecorate(t *testing.T) {
+	var logs []string
+	attempts := 0
+	flaky := func(ctx context.Context, name string) (int, error) {
+		if attempts++; attempts < 3 {
+			return 0, errors.New("try again")
+		}
+		return len(name), nil
+	}
+
+	decorated, err := funconv.Decorate(flaky, logCalls(&logs), retry(5))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	fn, ok := decorated.(func(context.Context, string) (int, error))
+	if !ok {
+		t.Fatalf("expected the type of flaky, got %T", decorated)
+	}
+
+	length, err := fn(context.Background(), "hello")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := 5, length; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 3, attempts; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// logCalls is the outermost, so it sees the retried call once
+	if want, have := 1, len(logs); want != have {
+		t.Fatalf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "func(context.Context, string) (int, error)[context.Background hello] -> [5 <nil>]", logs[0]; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestDecorate_recoverPanics(t *testing.T) {
+	type divideFunc func(a, b int) (int, error)
+	var divide divideFunc = func(a, b int) (int, error) {
+		return a / b, nil
+	}
+
+	decorated, err := funconv.Decorate(divide, recoverPanics)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	fn := decorated.(divideFunc)
+
+	quotient, err := fn(6, 3)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := 2, quotient; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, err = fn(1, 0)
+	if want, have := "panic: runtime error: integer divide by zero", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestDecorate_variadic(t *testing.T) {
+	join := func(sep string, names ...string) string {
+		return strings.Join(names, sep)
+	}
+
+	// a decorator modifying the arguments
+	upper := func(fnType reflect.Type, next funconv.Invoker) funconv.Invoker {
+		return func(args []reflect.Value) []reflect.Value {
+			names := args[1].Interface().([]string)
+			uppered := make([]string, len(names))
+			for i, name := range names {
+				uppered[i] = strings.ToUpper(name)
+			}
+			return next([]reflect.Value{args[0], reflect.ValueOf(uppered)})
+		}
+	}
+
+	decorated, err := funconv.Decorate(join, upper)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	fn := decorated.(func(string, ...string) string)
+	if want, have := "A,B", fn(",", "a", "b"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestDecorate_invalid(t *testing.T) {
+	for _, fn := range []interface{}{nil, "not a function"} {
+		_, err := funconv.Decorate(fn)
+		if !errors.Is(err, funconv.ErrInvalidFunc) {
+			t.Errorf("expected ErrInvalidFunc, got %#v", err)
+		}
+	}
+}
+
+func TestErrorResults(t *testing.T) {
+	someErr := errors.New("some error")
+	fnType := reflect.TypeOf(func() (string, *int, error) { return "", nil, nil })
+
+	results := funconv.ErrorResults(fnType, someErr)
+	if want, have := 3, len(results); want != have {
+		t.Fatalf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "", results[0].Interface(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := someErr, funconv.ResultError(results); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// nil error result
+	results = funconv.ErrorResults(fnType, nil)
+	if want, have := error(nil), funconv.ResultError(results); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic, got nil")
+		}
+	}()
+	funconv.ErrorResults(reflect.TypeOf(func() int { return 0 }), someErr)
+}
@@ -0,0 +1,162 @@
+package funconv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// mapElem maps the converter of container elements, with the
+// reason of mismatch prefixed by what the element is
+func (m *mapper) mapElem(what string, from, to reflect.Type) (mapped mappedConverter) {
+	if mapped = m.build(from, to); !mapped.ok {
+		mapped.reason = fmt.Errorf("%s, %s cannot be converted to %s%s",
+			what, from.String(), to.String(), reasonSuffix(mapped.reason))
+		return
+	}
+	if from.Kind() == reflect.Interface && !mapped.direct {
+		mapped.conv = zeroNil(mapped.conv, to)
+	}
+	return
+}
+
+// zeroNil returns the converter of elements, which converts nil
+// interfaces to the zero value of to, whatever type it is
+func zeroNil(conv Converter, to reflect.Type) Converter {
+	return func(src reflect.Value) (reflect.Value, error) {
+		if src.Kind() == reflect.Interface && src.IsNil() {
+			return reflect.Zero(to), nil
+		}
+		return conv(src)
+	}
+}
+
+// mapPtr maps the converter between pointer and pointer, or takes the
+// address of, or dereferences the value as needed. A nil pointer is
+// converted to the zero value of the destination.
+func (m *mapper) mapPtr(from, to reflect.Type) (mapped mappedConverter) {
+	fromElem, toElem := from, to
+	if from.Kind() == reflect.Ptr {
+		fromElem = from.Elem()
+	}
+	if to.Kind() == reflect.Ptr {
+		toElem = to.Elem()
+	}
+
+	elem := m.mapElem("pointer", fromElem, toElem)
+	if !elem.ok {
+		mapped.reason = elem.reason
+		return
+	}
+
+	mapped.ok = true
+	mapped.conv = func(src reflect.Value) (dest reflect.Value, err error) {
+		if from.Kind() == reflect.Ptr {
+			if src.IsNil() {
+				return reflect.Zero(to), nil
+			}
+			src = src.Elem()
+		}
+		if dest, err = elem.conv(src); err != nil || to.Kind() != reflect.Ptr {
+			return
+		}
+		ptr := reflect.New(toElem)
+		ptr.Elem().Set(dest)
+		return ptr, nil
+	}
+	return
+}
+
+// isList reports if typ is a slice or an array
+func isList(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array
+}
+
+// mapList maps the converter between slices and arrays element by
+// element. A nil slice is converted to the zero value of the destination.
+// Converting a slice to an array needs their lengths to be equal.
+func (m *mapper) mapList(from, to reflect.Type) (mapped mappedConverter) {
+	if from.Kind() == reflect.Array && to.Kind() == reflect.Array && from.Len() != to.Len() {
+		mapped.reason = fmt.Errorf("array length %d mismatch %d", from.Len(), to.Len())
+		return
+	}
+
+	elem := m.mapElem("element", from.Elem(), to.Elem())
+	if !elem.ok {
+		mapped.reason = elem.reason
+		return
+	}
+
+	mapped.ok = true
+	mapped.conv = func(src reflect.Value) (dest reflect.Value, err error) {
+		if src.Kind() == reflect.Slice && src.IsNil() {
+			return reflect.Zero(to), nil
+		}
+
+		length := src.Len()
+		if to.Kind() == reflect.Array {
+			if length != to.Len() {
+				err = fmt.Errorf("length %d cannot be converted to %s", length, to.String())
+				return
+			}
+			dest = reflect.New(to).Elem()
+		} else {
+			dest = reflect.MakeSlice(to, length, length)
+		}
+
+		for i := 0; i < length; i++ {
+			value := src.Index(i)
+			if !elem.direct {
+				if value, err = elem.conv(value); err != nil {
+					err = fmt.Errorf("element %d, %w", i, err)
+					return
+				}
+			}
+			dest.Index(i).Set(value)
+		}
+		return
+	}
+	return
+}
+
+// mapMap maps the converter between maps, converting keys and values.
+// A nil map is converted to a nil map of the destination.
+func (m *mapper) mapMap(from, to reflect.Type) (mapped mappedConverter) {
+	key := m.mapElem("key", from.Key(), to.Key())
+	if !key.ok {
+		mapped.reason = key.reason
+		return
+	}
+	elem := m.mapElem("value", from.Elem(), to.Elem())
+	if !elem.ok {
+		mapped.reason = elem.reason
+		return
+	}
+
+	mapped.ok = true
+	mapped.conv = func(src reflect.Value) (dest reflect.Value, err error) {
+		if src.IsNil() {
+			return reflect.Zero(to), nil
+		}
+
+		dest = reflect.MakeMapWithSize(to, src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			k, v := iter.Key(), iter.Value()
+			if !key.direct {
+				if k, err = key.conv(k); err != nil {
+					err = fmt.Errorf("key %v, %w", iter.Key(), err)
+					return
+				}
+			}
+			if !elem.direct {
+				if v, err = elem.conv(v); err != nil {
+					err = fmt.Errorf("value of key %v, %w", iter.Key(), err)
+					return
+				}
+			}
+			dest.SetMapIndex(k, v)
+		}
+		return
+	}
+	return
+}
@@ -0,0 +1,222 @@
+package funconv_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-midway/midway/funconv"
+)
+
+func TestWrap_slice(t *testing.T) {
+	join := func(items []fmt.Stringer) string {
+		if items == nil {
+			return "nil"
+		}
+		strs := make([]string, len(items))
+		for i := range items {
+			strs[i] = items[i].String()
+		}
+		return strings.Join(strs, ",")
+	}
+
+	var fromSlice func([]stringer) string
+	if err := funconv.WrapAs(join, &fromSlice); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if want, have := "some stringer,some stringer", fromSlice([]stringer{1, 2}); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "nil", fromSlice(nil); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	var fromArray func([2]stringer) string
+	if err := funconv.WrapAs(join, &fromArray); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if want, have := "some stringer,some stringer", fromArray([2]stringer{1, 2}); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_sliceToArray(t *testing.T) {
+	sum := func(nums [2]int) (int, error) {
+		return nums[0] + nums[1], nil
+	}
+	var endpoint func([]interface{}) (interface{}, error)
+	if err := funconv.WrapAs(sum, &endpoint); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	resp, err := endpoint([]interface{}{1, 2})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := 3, resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, err = endpoint([]interface{}{1, 2, 3})
+	if want, have := "argument 1, length 3 cannot be converted to [2]int", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, err = endpoint([]interface{}{1, "2"})
+	if want, have := "argument 1, element 1, string cannot be converted to int", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	var mismatch func([3]int) (interface{}, error)
+	err = funconv.WrapAs(sum, &mismatch)
+	if want, have := "argument 1, [3]int cannot be converted [2]int: array length 3 mismatch 2", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_map(t *testing.T) {
+	keys := func(m map[fmt.Stringer]int) (string, error) {
+		if m == nil {
+			return "nil", nil
+		}
+		strs := make([]string, 0, len(m))
+		for k, v := range m {
+			strs = append(strs, fmt.Sprintf("%s=%d", k, v))
+		}
+		sort.Strings(strs)
+		return strings.Join(strs, ","), nil
+	}
+	var endpoint func(map[stringer]interface{}) (string, error)
+	if err := funconv.WrapAs(keys, &endpoint); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	resp, err := endpoint(map[stringer]interface{}{1: 1})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := "some stringer=1", resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	resp, err = endpoint(nil)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := "nil", resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, err = endpoint(map[stringer]interface{}{1: "one"})
+	if want, have := "argument 1, value of key some stringer, string cannot be converted to int", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	var mismatch func(map[int]int) (string, error)
+	err = funconv.WrapAs(keys, &mismatch)
+	if want, have := "argument 1, map[int]int cannot be converted map[fmt.Stringer]int: key, int cannot be converted to fmt.Stringer", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_pointer(t *testing.T) {
+	double := func(n *int) int {
+		if n == nil {
+			return -1
+		}
+		return *n * 2
+	}
+	var addressOf func(int) int
+	if err := funconv.WrapAs(double, &addressOf); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if want, have := 4, addressOf(2); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	triple := func(n int) int {
+		return n * 3
+	}
+	var deref func(*int) int
+	if err := funconv.WrapAs(triple, &deref); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	n := 2
+	if want, have := 6, deref(&n); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 0, deref(nil); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// pointers of convertible elements
+	describe := func(s *fmt.Stringer) string {
+		if s == nil {
+			return "nil"
+		}
+		return (*s).String()
+	}
+	var ptrToPtr func(*stringer) string
+	if err := funconv.WrapAs(describe, &ptrToPtr); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	s := stringer(1)
+	if want, have := "some stringer", ptrToPtr(&s); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "nil", ptrToPtr(nil); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// return variables are dereferenced as well
+	var ret func() (int, error)
+	if err := funconv.WrapAs(func() (*int, error) { return nil, nil }, &ret); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if have, err := ret(); err != nil || have != 0 {
+		t.Errorf("expected 0, got %#v, %v", have, err)
+	}
+}
+
+func TestWrap_nilElements(t *testing.T) {
+	join := func(names []string) string {
+		return strings.Join(names, ",")
+	}
+	var fromSlice func([]interface{}) string
+	if err := funconv.WrapAs(join, &fromSlice); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if want, have := "a,", fromSlice([]interface{}{"a", nil}); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	describe := func(names map[string]string) string {
+		return fmt.Sprintf("%q", names)
+	}
+	var fromMap func(map[string]interface{}) string
+	if err := funconv.WrapAs(describe, &fromMap); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if want, have := `map["a":"" "b":"c"]`, fromMap(map[string]interface{}{"a": nil, "b": "c"}); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	count := func(ptrs []*int) int {
+		n := 0
+		for _, ptr := range ptrs {
+			if ptr != nil {
+				n++
+			}
+		}
+		return n
+	}
+	var fromPtrs func([]interface{}) int
+	if err := funconv.WrapAs(count, &fromPtrs); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	one := 1
+	if want, have := 1, fromPtrs([]interface{}{nil, &one}); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
@@ -0,0 +1,252 @@
+package funconv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strings"
+)
+
+var (
+	// ErrInvalidFunc is matched by errors.Is if the srcFunc or destFunc
+	// given to WrapAs is nil or not of the expected kind
+	ErrInvalidFunc = errors.New("invalid function")
+
+	// ErrArityMismatch is matched by errors.Is if the functions given
+	// to WrapAs have different number of arguments or return variables
+	ErrArityMismatch = errors.New("arity mismatch")
+
+	// ErrVariadicMismatch is matched by errors.Is if only one of the
+	// functions given to WrapAs is variadic, and the other does not
+	// take a slice as the last argument
+	ErrVariadicMismatch = errors.New("variadic mismatch")
+
+	// ErrTypeMismatch is matched by errors.Is if a type cannot be
+	// converted to another by WrapAs or MapConverter
+	ErrTypeMismatch = errors.New("type mismatch")
+
+	// ErrInvalidBinding is matched by errors.Is if the Bind, BindContext
+	// or ArgOrder given to WrapAs do not fit the functions
+	ErrInvalidBinding = errors.New("invalid binding")
+)
+
+// invalidFuncError is the error of WrapAs given an invalid function
+type invalidFuncError struct {
+	msg string
+}
+
+func (err invalidFuncError) Error() string {
+	return err.msg
+}
+
+func (err invalidFuncError) Is(target error) bool {
+	return target == ErrInvalidFunc
+}
+
+// invalidBindingError is the error of WrapAs given Bind, BindContext
+// or ArgOrder not fitting the functions
+type invalidBindingError struct {
+	msg string
+}
+
+func (err invalidBindingError) Error() string {
+	return err.msg
+}
+
+func (err invalidBindingError) Is(target error) bool {
+	return target == ErrInvalidBinding
+}
+
+// ArityMismatchError is the error of WrapAs given functions with
+// different number of arguments or return variables
+type ArityMismatchError struct {
+	ret  bool
+	src  int
+	dest int
+}
+
+// Return reports if the numbers of return variables, instead of
+// arguments, mismatch
+func (err ArityMismatchError) Return() bool {
+	return err.ret
+}
+
+// Src returns the number of srcFunc
+func (err ArityMismatchError) Src() int {
+	return err.src
+}
+
+// Dest returns the number of destFunc
+func (err ArityMismatchError) Dest() int {
+	return err.dest
+}
+
+func (err ArityMismatchError) Error() string {
+	what := "argument"
+	if err.ret {
+		what = "return"
+	}
+	return fmt.Sprintf("%s mismatch, srcFunc(%d) != destFunc(%d)", what, err.src, err.dest)
+}
+
+// Is reports if target is ErrArityMismatch
+func (err ArityMismatchError) Is(target error) bool {
+	return target == ErrArityMismatch
+}
+
+// VariadicMismatchError is the error of WrapAs given functions
+// of which only one is variadic, and the other does not take a slice
+// as the last argument
+type VariadicMismatchError struct {
+	srcVariadic bool
+}
+
+// SrcVariadic reports if srcFunc, instead of destFunc, is the
+// variadic function
+func (err VariadicMismatchError) SrcVariadic() bool {
+	return err.srcVariadic
+}
+
+func (err VariadicMismatchError) Error() string {
+	if err.srcVariadic {
+		return "srcFunc is variadic function while destFunc is not"
+	}
+	return "destFunc is variadic function while srcFunc is not"
+}
+
+// Is reports if target is ErrVariadicMismatch
+func (err VariadicMismatchError) Is(target error) bool {
+	return target == ErrVariadicMismatch
+}
+
+// mismatchKind is where the mismatched types are found
+type mismatchKind int
+
+const (
+	mismatchMapping mismatchKind = iota
+	mismatchArgument
+	mismatchRetVar
+)
+
+// TypeMismatchError is the error of WrapAs or MapConverter if a type
+// cannot be converted to another
+type TypeMismatchError struct {
+	kind   mismatchKind
+	pos    int
+	from   reflect.Type
+	to     reflect.Type
+	reason error
+}
+
+// Position returns the position (zero based) of the type in the
+// argument or return variable list, or the type slices of MapConverter
+func (err TypeMismatchError) Position() int {
+	return err.pos
+}
+
+// Return reports if the type is of a return variable
+func (err TypeMismatchError) Return() bool {
+	return err.kind == mismatchRetVar
+}
+
+// From returns the type to convert from
+func (err TypeMismatchError) From() reflect.Type {
+	return err.from
+}
+
+// To returns the type to convert to
+func (err TypeMismatchError) To() reflect.Type {
+	return err.to
+}
+
+func (err TypeMismatchError) Error() string {
+	switch err.kind {
+	case mismatchArgument:
+		return fmt.Sprintf("argument %d, %s cannot be converted %s%s",
+			err.pos+1, err.from.String(), err.to.String(), reasonSuffix(err.reason))
+	case mismatchRetVar:
+		return fmt.Sprintf("return variable %d, %s cannot be converted %s%s",
+			err.pos+1, err.from.String(), err.to.String(), reasonSuffix(err.reason))
+	}
+	return fmt.Sprintf("at %d, %s cannot be converted to %s%s",
+		err.pos, err.from.String(), err.to.String(), reasonSuffix(err.reason))
+}
+
+// Unwrap returns the reason of the mismatch, if any (e.g. the
+// mismatch of a struct field)
+func (err TypeMismatchError) Unwrap() error {
+	return err.reason
+}
+
+// Is reports if target is ErrTypeMismatch
+func (err TypeMismatchError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// TypeMismatchErrors is the error of WrapAs or MapConverter listing
+// every type which cannot be converted. It is never empty.
+type TypeMismatchErrors []*TypeMismatchError
+
+func (errs TypeMismatchErrors) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d type mismatches: %s", len(errs), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the mismatches, so errors.Is and errors.As match
+// ErrTypeMismatch and *TypeMismatchError
+func (errs TypeMismatchErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i, err := range errs {
+		unwrapped[i] = err
+	}
+	return unwrapped
+}
+
+// PanicError is the panic recovered by the wrapper of WrapAs with
+// RecoverPanics
+type PanicError struct {
+	value interface{}
+	stack []byte
+}
+
+func newPanicError(value interface{}) *PanicError {
+	return &PanicError{
+		value: value,
+		stack: debug.Stack(),
+	}
+}
+
+// Value returns the value of the panic
+func (err PanicError) Value() interface{} {
+	return err.value
+}
+
+// Stack returns the stack trace of the goroutine where it panicked
+func (err PanicError) Stack() []byte {
+	return err.stack
+}
+
+func (err PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", err.value)
+}
+
+// Unwrap returns the value of the panic if it is an error
+func (err PanicError) Unwrap() error {
+	unwrapped, _ := err.value.(error)
+	return unwrapped
+}
+
+// reasonSuffix formats the reason of a type mismatch, if any
+func reasonSuffix(reason error) string {
+	if reason == nil {
+		return ""
+	}
+	return ": " + reason.Error()
+}
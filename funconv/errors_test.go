@@ -0,0 +1,235 @@
+package funconv_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-midway/midway/funconv"
+)
+
+func TestWrap_invalidFuncError(t *testing.T) {
+	var dest func() error
+	tests := []struct {
+		src  interface{}
+		dest interface{}
+	}{
+		{src: nil, dest: &dest},
+		{src: func() error { return nil }, dest: nil},
+		{src: "not a function", dest: &dest},
+		{src: func() error { return nil }, dest: dest},
+	}
+	for i, test := range tests {
+		err := funconv.WrapAs(test.src, test.dest)
+		if !errors.Is(err, funconv.ErrInvalidFunc) {
+			t.Errorf("test %d: expected ErrInvalidFunc, got %#v", i, err)
+		}
+	}
+}
+
+func TestArityMismatchError(t *testing.T) {
+	lengthFunc := func(ctx context.Context, name string) (length int, err error) {
+		return len(name), nil
+	}
+
+	var mismatchArg func(req interface{}) (resp interface{}, err error)
+	err := funconv.WrapAs(lengthFunc, &mismatchArg)
+	if !errors.Is(err, funconv.ErrArityMismatch) {
+		t.Errorf("expected ErrArityMismatch, got %#v", err)
+	}
+	var arityErr *funconv.ArityMismatchError
+	if !errors.As(err, &arityErr) {
+		t.Fatalf("expected *funconv.ArityMismatchError, got %#v", err)
+	}
+	if want, have := false, arityErr.Return(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 2, arityErr.Src(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 1, arityErr.Dest(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	var mismatchRet func(ctx context.Context, req interface{}) (resp interface{})
+	err = funconv.WrapAs(lengthFunc, &mismatchRet)
+	if !errors.As(err, &arityErr) {
+		t.Fatalf("expected *funconv.ArityMismatchError, got %#v", err)
+	}
+	if want, have := true, arityErr.Return(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestVariadicMismatchError(t *testing.T) {
+	lengthArray := func(names ...string) int {
+		return len(names)
+	}
+	var dest func(name string) int
+	err := funconv.WrapAs(lengthArray, &dest)
+	if !errors.Is(err, funconv.ErrVariadicMismatch) {
+		t.Errorf("expected ErrVariadicMismatch, got %#v", err)
+	}
+	var variadicErr *funconv.VariadicMismatchError
+	if !errors.As(err, &variadicErr) {
+		t.Fatalf("expected *funconv.VariadicMismatchError, got %#v", err)
+	}
+	if want, have := true, variadicErr.SrcVariadic(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestTypeMismatchError(t *testing.T) {
+	type source struct {
+		Name int
+	}
+	type target struct {
+		Name string
+	}
+
+	inner := func(ctx context.Context, req target) (int, error) {
+		return 0, nil
+	}
+	var endpoint func(ctx context.Context, req source) (int, error)
+	err := funconv.WrapAs(inner, &endpoint)
+	if !errors.Is(err, funconv.ErrTypeMismatch) {
+		t.Errorf("expected ErrTypeMismatch, got %#v", err)
+	}
+	var mismatchErr *funconv.TypeMismatchError
+	if !errors.As(err, &mismatchErr) {
+		t.Fatalf("expected *funconv.TypeMismatchError, got %#v", err)
+	}
+	if want, have := 1, mismatchErr.Position(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := false, mismatchErr.Return(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := reflect.TypeOf(source{}), mismatchErr.From(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := reflect.TypeOf(target{}), mismatchErr.To(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if reason := mismatchErr.Unwrap(); reason == nil || !strings.Contains(reason.Error(), "field Name") {
+		t.Errorf("expected reason of field Name, got %#v", reason)
+	}
+
+	_, err = funconv.MapConverter(
+		[]reflect.Type{reflect.TypeOf("")},
+		[]reflect.Type{reflect.TypeOf(0)},
+	)
+	if !errors.As(err, &mismatchErr) {
+		t.Fatalf("expected *funconv.TypeMismatchError, got %#v", err)
+	}
+	if want, have := 0, mismatchErr.Position(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "at 0, string cannot be converted to int", err.Error(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestArgumentError_Unwrap(t *testing.T) {
+	errBad := errors.New("bad value")
+	var dest func(string, string) error
+	err := funconv.WrapAs(func(a, b int) error { return nil }, &dest,
+		funconv.WithRules(func(from, to reflect.Type) funconv.Converter {
+			return func(in reflect.Value) (reflect.Value, error) {
+				if in.String() == "bad" {
+					return reflect.Value{}, errBad
+				}
+				return reflect.ValueOf(len(in.String())), nil
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	err = dest("good", "bad")
+	if !errors.Is(err, errBad) {
+		t.Errorf("expected errBad, got %#v", err)
+	}
+	var argErr *funconv.ArgumentError
+	if !errors.As(err, &argErr) {
+		t.Fatalf("expected *funconv.ArgumentError, got %#v", err)
+	}
+	if want, have := 1, argErr.Position(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestRetVarError_Unwrap(t *testing.T) {
+	errOdd := errors.New("odd number")
+	var dest func() (string, error)
+	err := funconv.WrapAs(func() (int, error) { return 3, nil }, &dest,
+		funconv.WithRules(func(from, to reflect.Type) funconv.Converter {
+			return func(in reflect.Value) (reflect.Value, error) {
+				if in.Int()%2 == 1 {
+					return reflect.Value{}, errOdd
+				}
+				return reflect.ValueOf("even"), nil
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	_, err = dest()
+	if !errors.Is(err, errOdd) {
+		t.Errorf("expected errOdd, got %#v", err)
+	}
+	var retErr *funconv.RetVarError
+	if !errors.As(err, &retErr) {
+		t.Fatalf("expected *funconv.RetVarError, got %#v", err)
+	}
+	if want, have := 0, retErr.Position(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestTypeMismatchErrors(t *testing.T) {
+	inner := func(a string, b int, c string) (int, error) {
+		return 0, nil
+	}
+	var endpoint func(a int, b int, c int) (string, error)
+	err := funconv.WrapAs(inner, &endpoint)
+
+	var errs funconv.TypeMismatchErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected funconv.TypeMismatchErrors, got %#v", err)
+	}
+	if want, have := 3, len(errs); want != have {
+		t.Fatalf("expected %#v, got %#v", want, have)
+	}
+	if want, have := true, errs[2].Return(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "3 type mismatches: "+
+		"argument 1, int cannot be converted string; "+
+		"argument 3, int cannot be converted string; "+
+		"return variable 1, int cannot be converted string", err.Error(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if !errors.Is(err, funconv.ErrTypeMismatch) {
+		t.Errorf("expected ErrTypeMismatch, got %#v", err)
+	}
+
+	converters, err := funconv.MapConverter(
+		[]reflect.Type{reflect.TypeOf(""), reflect.TypeOf(0), reflect.TypeOf(true)},
+		[]reflect.Type{reflect.TypeOf(0), reflect.TypeOf(0), reflect.TypeOf("")},
+	)
+	if want, have := "2 type mismatches: "+
+		"at 0, string cannot be converted to int; "+
+		"at 2, bool cannot be converted to string", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if converters[1] == nil {
+		t.Errorf("expected the converter of compatible types")
+	}
+}
@@ -0,0 +1,92 @@
+package funconv
+
+import (
+	"context"
+	"reflect"
+)
+
+// typeOf returns the reflect.Type of T, even if T is an interface
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// assertTo converts src into T, following the same rule of WrapAs
+// when the wrapper takes interface{} where the function takes T
+func assertTo[T any](src interface{}) (dest T, err error) {
+	if v, ok := src.(T); ok {
+		return v, nil
+	}
+
+	// src is converted as an interface, so nil is converted as WrapAs does
+	out, err := reverseConvertToItfType(typeOf[T]())(reflect.ValueOf(&src).Elem())
+	if err != nil {
+		return
+	}
+	dest, _ = out.Interface().(T)
+	return
+}
+
+// Wrap1 turns a function of one argument and one return variable into
+// a function of interface{}, with the types checked at compile time.
+//
+// Like WrapAs with a wrapper without error return variable, the
+// wrapper panics with *ArgumentError if its argument is not an In.
+func Wrap1[In, Out any](fn func(In) Out) func(interface{}) interface{} {
+	return func(arg interface{}) interface{} {
+		in, err := assertTo[In](arg)
+		if err != nil {
+			panic(&ArgumentError{pos: 0, err: err})
+		}
+		return fn(in)
+	}
+}
+
+// Wrap1E turns a function of one argument, one return variable and an
+// error into a function of interface{}, with the types checked at
+// compile time.
+//
+// The wrapper returns *ArgumentError if its argument is not an In.
+func Wrap1E[In, Out any](fn func(In) (Out, error)) func(interface{}) (interface{}, error) {
+	return func(arg interface{}) (interface{}, error) {
+		in, err := assertTo[In](arg)
+		if err != nil {
+			return nil, &ArgumentError{pos: 0, err: err}
+		}
+		return fn(in)
+	}
+}
+
+// WrapEndpoint turns a typed service method into a go-kit style
+// endpoint function, with the types checked at compile time.
+//
+// The endpoint returns *ArgumentError if the request is not a Req.
+func WrapEndpoint[Req, Resp any](fn func(context.Context, Req) (Resp, error)) func(context.Context, interface{}) (interface{}, error) {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, err := assertTo[Req](request)
+		if err != nil {
+			return nil, &ArgumentError{pos: 1, err: err}
+		}
+		return fn(ctx, req)
+	}
+}
+
+// DecorateFunc is Decorate with the type of fn checked at compile time.
+// It panics if F is not a function type.
+func DecorateFunc[F any](fn F, decorators ...Decorator) F {
+	decorated, err := Decorate(fn, decorators...)
+	if err != nil {
+		panic(err)
+	}
+	return decorated.(F)
+}
+
+// ConvertChanOf is ConvertChan with the element type T checked at
+// compile time
+func ConvertChanOf[T any](ctx context.Context, src interface{}, opts ...Option) (dest <-chan T, errs <-chan error, err error) {
+	converted, errs, err := ConvertChan(ctx, src, typeOf[<-chan T](), opts...)
+	if err != nil {
+		return
+	}
+	dest = converted.(<-chan T)
+	return
+}
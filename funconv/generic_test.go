@@ -0,0 +1,165 @@
+package funconv_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-midway/midway/funconv"
+)
+
+func TestWrap1(t *testing.T) {
+	length := funconv.Wrap1(func(name string) int {
+		return len(name)
+	})
+	if want, have := 5, length("hello"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	describe := funconv.Wrap1(func(s fmt.Stringer) string {
+		return s.String()
+	})
+	if want, have := "some stringer", describe(stringer(1)); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := interface{}(nil), funconv.Wrap1(func(s fmt.Stringer) fmt.Stringer {
+		return s
+	})(nil); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	defer func() {
+		r := recover()
+		err, ok := r.(*funconv.ArgumentError)
+		if !ok {
+			t.Errorf("expected *funconv.ArgumentError, got %#v", r)
+		} else if want, have := "argument 1, int cannot be converted to string", err.Error(); want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+	}()
+	length(123)
+}
+
+func TestWrap1E(t *testing.T) {
+	parse := funconv.Wrap1E(func(name string) (int, error) {
+		if name == "" {
+			return 0, fmt.Errorf("empty name")
+		}
+		return len(name), nil
+	})
+
+	resp, err := parse("hello")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := 5, resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, err = parse("")
+	if want, have := "empty name", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, err = parse(nil)
+	if want, have := "argument 1, <nil> cannot be converted to string", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrapEndpoint(t *testing.T) {
+	lengthFunc := func(ctx context.Context, name string) (length int, err error) {
+		length = len(name)
+		return
+	}
+
+	// the same function wrapped by WrapAs
+	var reflective func(ctx context.Context, req interface{}) (resp interface{}, err error)
+	if err := funconv.WrapAs(lengthFunc, &reflective); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	generic := funconv.WrapEndpoint(lengthFunc)
+
+	for _, req := range []interface{}{"hello world", 123} {
+		wantResp, wantErr := reflective(nil, req)
+		haveResp, haveErr := generic(nil, req)
+		if fmt.Sprintf("%v", wantErr) != fmt.Sprintf("%v", haveErr) {
+			t.Errorf("request %#v: expected error %#v, got %#v", req, wantErr, haveErr)
+			continue
+		}
+		if wantErr == nil && wantResp != haveResp {
+			t.Errorf("request %#v: expected %#v, got %#v", req, wantResp, haveResp)
+		}
+		if _, ok := haveErr.(*funconv.ArgumentError); haveErr != nil && !ok {
+			t.Errorf("request %#v: expected *funconv.ArgumentError, got %#v", req, haveErr)
+		}
+	}
+}
+
+func TestDecorateFunc(t *testing.T) {
+	calls := 0
+	count := func(fnType reflect.Type, next funconv.Invoker) funconv.Invoker {
+		return func(args []reflect.Value) []reflect.Value {
+			calls++
+			return next(args)
+		}
+	}
+
+	length := funconv.DecorateFunc(func(name string) int {
+		return len(name)
+	}, count)
+	if want, have := 5, length("hello"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 1, calls; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic, got nil")
+		}
+	}()
+	funconv.DecorateFunc("not a function", count)
+}
+
+func TestConvertChanOf(t *testing.T) {
+	src := make(chan interface{}, 2)
+	src <- "hello"
+	src <- 42
+	close(src)
+
+	dest, errs, err := funconv.ConvertChanOf[string](context.Background(), src)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	var values, errMsgs []string
+	for dest != nil || errs != nil {
+		select {
+		case v, ok := <-dest:
+			if !ok {
+				dest = nil
+				continue
+			}
+			values = append(values, v)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+	if want, have := "[hello]", fmt.Sprintf("%v", values); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "[element 2, int cannot be converted to string]", fmt.Sprintf("%v", errMsgs); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, _, err = funconv.ConvertChanOf[string](context.Background(), "not a channel")
+	if want, have := "src needs to be a channel to receive from, got string", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
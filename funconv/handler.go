@@ -0,0 +1,262 @@
+package funconv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/go-midway/midway/logcontext"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// StatusCoder is implemented by the errors, or responses, of the
+// function served by Handler to set the status code of the response
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// badRequestError is the error of decoding a request
+type badRequestError struct {
+	err error
+}
+
+func (err badRequestError) Error() string {
+	return err.err.Error()
+}
+
+func (err badRequestError) Unwrap() error {
+	return err.err
+}
+
+func (err badRequestError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// errorResponse is the JSON body of a failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// wrapEndpoint wraps fn, of the form func(context.Context, Req) (Resp, error),
+// as a go-kit style endpoint function. It returns the type Req.
+func wrapEndpoint(fn interface{}, opts []Option) (ep func(ctx context.Context, req interface{}) (interface{}, error), reqType reflect.Type, err error) {
+	fnType := reflect.TypeOf(fn)
+	if fnType == nil || fnType.Kind() != reflect.Func ||
+		fnType.NumIn() != 2 || fnType.NumOut() != 2 ||
+		fnType.In(0) != contextType || fnType.Out(1) != errorType {
+		err = &invalidFuncError{msg: fmt.Sprintf(
+			"fn needs to be func(context.Context, Req) (Resp, error), got %T", fn)}
+		return
+	}
+	if err = WrapAs(fn, &ep, opts...); err != nil {
+		return
+	}
+	reqType = fnType.In(1)
+	return
+}
+
+// paramField is a field of Req to decode from the query string
+// or the path values
+type paramField struct {
+	index  []int
+	source string
+	name   string
+	multi  bool
+	conv   Converter
+}
+
+// requestDecoder decodes a *http.Request into Req
+type requestDecoder struct {
+	typ    reflect.Type
+	params []paramField
+}
+
+// newRequestDecoder maps the converters of the fields of typ, or of the
+// struct typ points to, tagged with `query:"name"` or `path:"name"`
+func newRequestDecoder(typ reflect.Type, opts []Option) (dec *requestDecoder, err error) {
+	dec = &requestDecoder{typ: typ}
+	structType := typ
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return
+	}
+
+	opts = append(opts[:len(opts):len(opts)], WithRules(ParseStrings, TextMarshaling))
+	m := newConfig(opts).mapper()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		for _, source := range []string{"path", "query"} {
+			name, ok := field.Tag.Lookup(source)
+			if !ok || name == "" {
+				continue
+			}
+
+			// query values of a key may be many
+			from := reflect.TypeOf("")
+			multi := source == "query" && field.Type.Kind() == reflect.Slice
+			if multi {
+				from = reflect.TypeOf([]string(nil))
+			}
+
+			mapped := m.mapType(from, field.Type)
+			if !mapped.ok {
+				err = fmt.Errorf("field %s, %s cannot be converted to %s%s",
+					field.Name, from.String(), field.Type.String(), reasonSuffix(mapped.reason))
+				return
+			}
+			dec.params = append(dec.params, paramField{
+				index:  field.Index,
+				source: source,
+				name:   name,
+				multi:  multi,
+				conv:   mapped.conv,
+			})
+		}
+	}
+	return
+}
+
+// decode decodes the JSON body, then the query string and the path
+// values, of r into a new Req
+func (dec *requestDecoder) decode(r *http.Request) (req interface{}, err error) {
+	reqPtr := reflect.New(dec.typ)
+	target := reqPtr
+	if dec.typ.Kind() == reflect.Ptr {
+		target = reflect.New(dec.typ.Elem())
+		reqPtr.Elem().Set(target)
+	}
+
+	if r.Body != nil && r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(target.Interface())
+		if err != nil && err != io.EOF {
+			return nil, &badRequestError{err: fmt.Errorf("invalid JSON body: %w", err)}
+		}
+		err = nil
+	}
+
+	var query map[string][]string
+	for _, param := range dec.params {
+		var value reflect.Value
+		switch param.source {
+		case "path":
+			str := r.PathValue(param.name)
+			if str == "" {
+				continue
+			}
+			value = reflect.ValueOf(str)
+		default:
+			if query == nil {
+				query = r.URL.Query()
+			}
+			values, ok := query[param.name]
+			if !ok {
+				continue
+			}
+			if param.multi {
+				value = reflect.ValueOf(values)
+			} else {
+				value = reflect.ValueOf(values[0])
+			}
+		}
+
+		if value, err = param.conv(value); err != nil {
+			return nil, &badRequestError{err: fmt.Errorf(
+				"%s parameter %s, %w", param.source, param.name, err)}
+		}
+		target.Elem().FieldByIndex(param.index).Set(value)
+	}
+
+	return reqPtr.Elem().Interface(), nil
+}
+
+// writeJSON writes v as the JSON response
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// errorStatus returns the status code and the message to respond
+// with err. Errors not implementing StatusCoder are internal, so their
+// messages are not exposed.
+func errorStatus(err error) (code int, msg string) {
+	var coder StatusCoder
+	if errors.As(err, &coder) {
+		return coder.StatusCode(), err.Error()
+	}
+	return http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+}
+
+// logError logs err, responded with the status code, with the
+// loggers of logcontext
+func logError(ctx context.Context, code int, err error, keyvals ...interface{}) {
+	logger := logcontext.GetComplexLogger(ctx)
+	log, at := logger.Log, "warning"
+	if code >= http.StatusInternalServerError {
+		log, at = logger.Error, "error"
+	}
+	keyvals = append([]interface{}{"at", at}, keyvals...)
+	log(append(keyvals, "status", code, "err", err.Error())...)
+}
+
+// writeError writes the JSON response of err and logs it
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	code, msg := errorStatus(err)
+	logError(r.Context(), code, err, "method", r.Method, "path", r.URL.Path)
+	writeJSON(w, code, errorResponse{Error: msg})
+}
+
+// Handler serves fn, of the form func(context.Context, Req) (Resp, error),
+// as an http.Handler.
+//
+// The JSON body of a request is decoded into Req. If Req is a struct, or
+// pointer to struct, its fields tagged `query:"name"` or `path:"name"` are
+// then decoded from the query string or the path values of the request
+// (see http.Request.PathValue) with the ParseStrings and TextMarshaling
+// rules. A slice field takes every query value of the name.
+//
+// Resp is encoded as JSON. If Resp implements StatusCoder, its status
+// code is used instead of 200 OK. If fn returns an error implementing
+// StatusCoder, the status code and the error message are written as
+// {"error": message}; other errors are 500 Internal Server Error.
+// Requests failed to decode are 400 Bad Request. Failures are logged
+// with the loggers of logcontext.
+func Handler(fn interface{}, opts ...Option) (handler http.Handler, err error) {
+	ep, reqType, err := wrapEndpoint(fn, opts)
+	if err != nil {
+		return
+	}
+	dec, err := newRequestDecoder(reqType, opts)
+	if err != nil {
+		return
+	}
+
+	handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := dec.decode(r)
+		var resp interface{}
+		if err == nil {
+			resp, err = ep(r.Context(), req)
+		}
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		code := http.StatusOK
+		if coder, ok := resp.(StatusCoder); ok {
+			code = coder.StatusCode()
+		}
+		writeJSON(w, code, resp)
+	})
+	return
+}
@@ -0,0 +1,214 @@
+package funconv_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-midway/midway/funconv"
+	"github.com/go-midway/midway/logcontext"
+)
+
+type noteRequest struct {
+	UserID int           `json:"-" path:"id"`
+	Draft  bool          `json:"-" query:"draft"`
+	Tags   []string      `json:"-" query:"tag"`
+	TTL    time.Duration `json:"-" query:"ttl"`
+	Title  string        `json:"title"`
+}
+
+type noteResponse struct {
+	Note string `json:"note"`
+}
+
+func (resp noteResponse) StatusCode() int {
+	return http.StatusCreated
+}
+
+// notFoundError is an error with status code
+type notFoundError struct {
+	id int
+}
+
+func (err notFoundError) Error() string {
+	return fmt.Sprintf("user %d not found", err.id)
+}
+
+func (err notFoundError) StatusCode() int {
+	return http.StatusNotFound
+}
+
+func createNote(ctx context.Context, req noteRequest) (noteResponse, error) {
+	switch req.UserID {
+	case 404:
+		return noteResponse{}, notFoundError{id: req.UserID}
+	case 500:
+		return noteResponse{}, errors.New("database is down")
+	}
+	return noteResponse{Note: fmt.Sprintf("%d %q %v %s %s",
+		req.UserID, req.Title, req.Draft, strings.Join(req.Tags, ","), req.TTL)}, nil
+}
+
+// serveNotes serves createNote with loggers writing to info and errs
+func serveNotes(t *testing.T, info, errs *bytes.Buffer) http.Handler {
+	handler, err := funconv.Handler(createNote)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	mux := http.NewServeMux()
+	mux.Handle("POST /users/{id}/notes", handler)
+	return logcontext.ProvideLoggers(
+		kitlog.NewLogfmtLogger(info),
+		kitlog.NewLogfmtLogger(errs),
+	)(mux)
+}
+
+func TestHandler(t *testing.T) {
+	info, errs := &bytes.Buffer{}, &bytes.Buffer{}
+	handler := serveNotes(t, info, errs)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/users/42/notes?draft=true&tag=a&tag=b&ttl=1m",
+		strings.NewReader(`{"title": "hello"}`))
+	handler.ServeHTTP(w, r)
+
+	if want, have := http.StatusCreated, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "application/json; charset=utf-8", w.Header().Get("Content-Type"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := `{"note":"42 \"hello\" true a,b 1m0s"}`+"\n", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "", info.String()+errs.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestHandler_badRequest(t *testing.T) {
+	tests := []struct {
+		target string
+		body   string
+		want   string
+	}{
+		{
+			target: "/users/42/notes",
+			body:   `{"title": 123}`,
+			want:   `invalid JSON body: json: cannot unmarshal number into Go struct field noteRequest.title of type string`,
+		},
+		{
+			target: "/users/forty-two/notes",
+			want:   `path parameter id, "forty-two" cannot be parsed as int: invalid syntax`,
+		},
+		{
+			target: "/users/42/notes?draft=maybe",
+			want:   `query parameter draft, "maybe" cannot be parsed as bool: invalid syntax`,
+		},
+	}
+	for _, test := range tests {
+		info, errs := &bytes.Buffer{}, &bytes.Buffer{}
+		handler := serveNotes(t, info, errs)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", test.target, strings.NewReader(test.body))
+		handler.ServeHTTP(w, r)
+
+		if want, have := http.StatusBadRequest, w.Code; want != have {
+			t.Errorf("%s: expected %#v, got %#v", test.target, want, have)
+		}
+		if want, have := fmt.Sprintf("{\"error\":%q}\n", test.want), w.Body.String(); want != have {
+			t.Errorf("%s: expected %#v, got %#v", test.target, want, have)
+		}
+		if want := "at=warning"; !strings.Contains(info.String(), want) {
+			t.Errorf("%s: expected log to contain %#v, got %#v", test.target, want, info.String())
+		}
+	}
+}
+
+func TestHandler_errors(t *testing.T) {
+	info, errs := &bytes.Buffer{}, &bytes.Buffer{}
+	handler := serveNotes(t, info, errs)
+
+	// error of StatusCoder
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/users/404/notes", nil))
+	if want, have := http.StatusNotFound, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := `{"error":"user 404 not found"}`+"\n", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want := `at=warning method=POST path=/users/404/notes status=404 err="user 404 not found"`; !strings.Contains(info.String(), want) {
+		t.Errorf("expected log to contain %#v, got %#v", want, info.String())
+	}
+
+	// internal error
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/users/500/notes", nil))
+	if want, have := http.StatusInternalServerError, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := `{"error":"Internal Server Error"}`+"\n", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want := `at=error method=POST path=/users/500/notes status=500 err="database is down"`; !strings.Contains(errs.String(), want) {
+		t.Errorf("expected log to contain %#v, got %#v", want, errs.String())
+	}
+}
+
+func TestHandler_pointerRequest(t *testing.T) {
+	type searchRequest struct {
+		Query string `query:"q"`
+		Page  int    `query:"page"`
+	}
+	handler, err := funconv.Handler(func(ctx context.Context, req *searchRequest) (interface{}, error) {
+		return []interface{}{req.Query, req.Page}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/search?q=gopher&page=2", nil))
+	if want, have := http.StatusOK, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := `["gopher",2]`+"\n", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestHandler_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   interface{}
+	}{
+		{name: "nil", fn: nil},
+		{name: "no context", fn: func(req string) (string, error) { return req, nil }},
+		{name: "no error", fn: func(ctx context.Context, req string) string { return req }},
+	}
+	for _, test := range tests {
+		_, err := funconv.Handler(test.fn)
+		if !errors.Is(err, funconv.ErrInvalidFunc) {
+			t.Errorf("%s: expected ErrInvalidFunc, got %#v", test.name, err)
+		}
+	}
+
+	type badRequest struct {
+		Callback func() `query:"callback"`
+	}
+	_, err := funconv.Handler(func(ctx context.Context, req badRequest) (string, error) {
+		return "", nil
+	})
+	if want, have := "field Callback, string cannot be converted to func()", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
@@ -0,0 +1,95 @@
+package funconv
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+var (
+	responseWriterType = reflect.TypeOf((*http.ResponseWriter)(nil)).Elem()
+	requestType        = reflect.TypeOf((*http.Request)(nil))
+)
+
+// Provide adds providers, each a func(context.Context) T, to resolve the
+// arguments of type T of the handlers of InjectHandler. For example,
+// logcontext.GetLogger or gormcontext.GetDB. A provider replaces the
+// providers of the same type given before.
+func Provide(providers ...interface{}) Option {
+	return func(c *config) {
+		c.providers = append(c.providers, providers...)
+	}
+}
+
+// providerMap returns the providers of the configuration by type
+func (c *config) providerMap() (providers map[reflect.Type]reflect.Value, err error) {
+	providers = make(map[reflect.Type]reflect.Value, len(c.providers)+1)
+	for _, provider := range c.providers {
+		providerVal := reflect.ValueOf(provider)
+		if providerVal.Kind() != reflect.Func || providerVal.Type().NumIn() != 1 ||
+			providerVal.Type().In(0) != contextType || providerVal.Type().NumOut() != 1 {
+			err = &invalidFuncError{msg: fmt.Sprintf(
+				"provider needs to be func(context.Context) T, got %T", provider)}
+			return
+		}
+		providers[providerVal.Type().Out(0)] = providerVal
+	}
+	return
+}
+
+// InjectHandler serves fn, of the form
+//
+//	func(w http.ResponseWriter, r *http.Request, a A, b B, ...) [error]
+//
+// as an http.HandlerFunc. The arguments after r are resolved by type from
+// the context of the request with the providers given by Provide. An
+// argument of type context.Context is the context of the request, unless
+// a provider is given for it. Arguments without provider are errors of
+// InjectHandler.
+//
+// If fn returns an error, it is written and logged as Handler does.
+func InjectHandler(fn interface{}, opts ...Option) (handler http.HandlerFunc, err error) {
+	fnType := reflect.TypeOf(fn)
+	if fnType == nil || fnType.Kind() != reflect.Func || fnType.IsVariadic() ||
+		fnType.NumIn() < 2 || fnType.In(0) != responseWriterType || fnType.In(1) != requestType ||
+		fnType.NumOut() > 1 || (fnType.NumOut() == 1 && fnType.Out(0) != errorType) {
+		err = &invalidFuncError{msg: fmt.Sprintf(
+			"fn needs to be func(http.ResponseWriter, *http.Request, ...) [error], got %T", fn)}
+		return
+	}
+
+	providers, err := newConfig(opts).providerMap()
+	if err != nil {
+		return
+	}
+	args := make([]reflect.Value, fnType.NumIn()-2)
+	for i := range args {
+		typ := fnType.In(i + 2)
+		provider, ok := providers[typ]
+		if !ok && typ != contextType {
+			err = fmt.Errorf("argument %d, no provider of %s", i+3, typ.String())
+			return
+		}
+		args[i] = provider
+	}
+
+	fnVal := reflect.ValueOf(fn)
+	handler = func(w http.ResponseWriter, r *http.Request) {
+		ctx := reflect.ValueOf(r.Context())
+		in := make([]reflect.Value, 0, fnType.NumIn())
+		in = append(in, reflect.ValueOf(w), reflect.ValueOf(r))
+		for _, provider := range args {
+			if !provider.IsValid() {
+				in = append(in, ctx)
+				continue
+			}
+			in = append(in, provider.Call([]reflect.Value{ctx})[0])
+		}
+
+		out := fnVal.Call(in)
+		if len(out) == 1 && !out[0].IsNil() {
+			writeError(w, r, out[0].Interface().(error))
+		}
+	}
+	return
+}
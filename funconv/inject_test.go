@@ -0,0 +1,120 @@
+package funconv_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-midway/midway/db/gormcontext"
+	"github.com/go-midway/midway/funconv"
+	"github.com/go-midway/midway/logcontext"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestInjectHandler(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	defer db.Close()
+
+	handler, err := funconv.InjectHandler(func(w http.ResponseWriter, r *http.Request, logger kitlog.Logger, gotDB *gorm.DB, ctx context.Context) error {
+		if gotDB != db {
+			return errors.New("unexpected db")
+		}
+		if ctx != r.Context() {
+			return errors.New("unexpected context")
+		}
+		logger.Log("msg", "hello")
+		fmt.Fprint(w, "hello "+r.URL.Query().Get("name"))
+		return nil
+	}, funconv.Provide(logcontext.GetLogger, gormcontext.GetDB))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	logs := &bytes.Buffer{}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/?name=gopher", nil)
+	ctx := logcontext.WithLogger(gormcontext.WithDB(r.Context(), db), kitlog.NewLogfmtLogger(logs))
+	handler.ServeHTTP(w, r.WithContext(ctx))
+	if want, have := http.StatusOK, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "hello gopher", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "msg=hello\n", logs.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestInjectHandler_error(t *testing.T) {
+	handler, err := funconv.InjectHandler(func(w http.ResponseWriter, r *http.Request, logger logcontext.ComplexLogger) error {
+		return notFoundError{id: 404}
+	}, funconv.Provide(logcontext.GetComplexLogger))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	logs := &bytes.Buffer{}
+	w := httptest.NewRecorder()
+	logcontext.ProvideLoggers(kitlog.NewLogfmtLogger(logs), kitlog.NewNopLogger())(handler).
+		ServeHTTP(w, httptest.NewRequest("GET", "/users/404", nil))
+	if want, have := http.StatusNotFound, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := `{"error":"user 404 not found"}`+"\n", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want := `at=warning method=GET path=/users/404 status=404 err="user 404 not found"`; !strings.Contains(logs.String(), want) {
+		t.Errorf("expected log to contain %#v, got %#v", want, logs.String())
+	}
+}
+
+func TestInjectHandler_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   interface{}
+		opts []funconv.Option
+		want string
+	}{
+		{
+			name: "not a handler",
+			fn:   func(ctx context.Context) error { return nil },
+			want: "fn needs to be func(http.ResponseWriter, *http.Request, ...) [error], got func(context.Context) error",
+		},
+		{
+			name: "return variables",
+			fn:   func(w http.ResponseWriter, r *http.Request) string { return "" },
+			want: "fn needs to be func(http.ResponseWriter, *http.Request, ...) [error], got func(http.ResponseWriter, *http.Request) string",
+		},
+		{
+			name: "invalid provider",
+			fn:   func(w http.ResponseWriter, r *http.Request) {},
+			opts: []funconv.Option{funconv.Provide(gormcontext.GetNamedDB)},
+			want: "provider needs to be func(context.Context) T, got func(context.Context, gormcontext.DBName) *gorm.DB",
+		},
+		{
+			name: "no provider",
+			fn:   func(w http.ResponseWriter, r *http.Request, logger kitlog.Logger, db *gorm.DB) {},
+			opts: []funconv.Option{funconv.Provide(logcontext.GetLogger)},
+			want: "argument 4, no provider of *gorm.DB",
+		},
+	}
+	for _, test := range tests {
+		_, err := funconv.InjectHandler(test.fn, test.opts...)
+		if err == nil {
+			t.Errorf("%s: expected error, got nil", test.name)
+		} else if want, have := test.want, err.Error(); want != have {
+			t.Errorf("%s: expected %#v, got %#v", test.name, want, have)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Package gentest holds the functions wrapped by funconv-gen, to test the
+// generated wrappers against funconv.WrapAs.
+package gentest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+//go:generate go run ../../../cmd/funconv-gen -o gentest_gen.go Length:LengthFunc Length:InterfaceLengthFunc Check:CheckFunc ContextLength:Endpoint FaultyLength:LengthEndpoint FaultyLengthNoError:LengthNoErrorFunc Describe:StringerFunc DescribeStringer:FmtStringerFunc CountNames:CountEndpoint CountNames:CountSliceEndpoint CountAll:Endpoint SplitNames:NamesFunc
+
+type stringer int
+
+func (s stringer) String() string {
+	return "some stringer"
+}
+
+// Length returns the length of name
+func Length(name string) int {
+	return len(name)
+}
+
+// Check returns error for empty name
+func Check(name string) error {
+	if name == "" {
+		return fmt.Errorf("some error")
+	}
+	return nil
+}
+
+// ContextLength returns the length of name
+func ContextLength(ctx context.Context, name string) (int, error) {
+	return len(name), nil
+}
+
+// FaultyLength returns a length of wrong type
+func FaultyLength(ctx context.Context, name string) (interface{}, error) {
+	return "some length", nil
+}
+
+// FaultyLengthNoError returns a length of wrong type
+func FaultyLengthNoError(ctx context.Context, name string) interface{} {
+	return "some length"
+}
+
+// Describe describes a stringer
+func Describe(s stringer) string {
+	return s.String()
+}
+
+// DescribeStringer describes a fmt.Stringer
+func DescribeStringer(s fmt.Stringer) string {
+	return s.String()
+}
+
+// CountNames returns the number of names
+func CountNames(ctx context.Context, names ...string) (int, error) {
+	return len(names), nil
+}
+
+// CountAll returns the number of names
+func CountAll(ctx context.Context, names []string) (int, error) {
+	return len(names), nil
+}
+
+// SplitNames splits the comma separated names, or returns nil for
+// empty name
+func SplitNames(ctx context.Context, name string) interface{} {
+	if name == "" {
+		return nil
+	}
+	return strings.Split(name, ",")
+}
+
+// LengthFunc is the type of Length
+type LengthFunc func(name string) int
+
+// InterfaceLengthFunc takes an interface{} as name
+type InterfaceLengthFunc func(name interface{}) int
+
+// CheckFunc is the type of Check
+type CheckFunc func(name string) error
+
+// Endpoint is the go-kit style endpoint
+type Endpoint func(ctx context.Context, req interface{}) (interface{}, error)
+
+// LengthEndpoint returns length as int
+type LengthEndpoint func(ctx context.Context, name string) (int, error)
+
+// LengthNoErrorFunc returns length as int without error
+type LengthNoErrorFunc func(ctx context.Context, name string) int
+
+// StringerFunc takes a fmt.Stringer
+type StringerFunc func(s fmt.Stringer) string
+
+// FmtStringerFunc takes a stringer
+type FmtStringerFunc func(s stringer) string
+
+// CountEndpoint takes variadic names
+type CountEndpoint func(ctx context.Context, names ...string) (interface{}, error)
+
+// CountSliceEndpoint takes names as a slice
+type CountSliceEndpoint func(ctx context.Context, names []string) (interface{}, error)
+
+// NamesFunc returns names as []string
+type NamesFunc func(ctx context.Context, name string) []string
@@ -0,0 +1,154 @@
+// Code generated by funconv-gen. DO NOT EDIT.
+
+package gentest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-midway/midway/funconv"
+)
+
+// LengthAsLengthFunc wraps Length as LengthFunc
+func LengthAsLengthFunc() LengthFunc {
+	return func(a0 string) (r0 int) {
+		s0 := Length(a0)
+		r0 = s0
+		return
+	}
+}
+
+// LengthAsInterfaceLengthFunc wraps Length as InterfaceLengthFunc
+func LengthAsInterfaceLengthFunc() InterfaceLengthFunc {
+	return func(a0 interface{}) (r0 int) {
+		b0, ok := a0.(string)
+		if !ok {
+			panic(funconv.NewArgumentError(0, fmt.Errorf("%T cannot be converted to %s", a0, "string")))
+		}
+		s0 := Length(b0)
+		r0 = s0
+		return
+	}
+}
+
+// CheckAsCheckFunc wraps Check as CheckFunc
+func CheckAsCheckFunc() CheckFunc {
+	return func(a0 string) (r0 error) {
+		s0 := Check(a0)
+		r0 = s0
+		return
+	}
+}
+
+// ContextLengthAsEndpoint wraps ContextLength as Endpoint
+func ContextLengthAsEndpoint() Endpoint {
+	return func(a0 context.Context, a1 interface{}) (r0 interface{}, r1 error) {
+		b1, ok := a1.(string)
+		if !ok {
+			r1 = funconv.NewArgumentError(1, fmt.Errorf("%T cannot be converted to %s", a1, "string"))
+			return
+		}
+		s0, s1 := ContextLength(a0, b1)
+		r0 = s0
+		r1 = s1
+		return
+	}
+}
+
+// FaultyLengthAsLengthEndpoint wraps FaultyLength as LengthEndpoint
+func FaultyLengthAsLengthEndpoint() LengthEndpoint {
+	return func(a0 context.Context, a1 string) (r0 int, r1 error) {
+		s0, s1 := FaultyLength(a0, a1)
+		if v, ok := s0.(int); ok {
+			r0 = v
+		} else {
+			r1 = funconv.NewRetVarError(0, fmt.Errorf("%T cannot be converted to %s", s0, "int"))
+			return
+		}
+		r1 = s1
+		return
+	}
+}
+
+// FaultyLengthNoErrorAsLengthNoErrorFunc wraps FaultyLengthNoError as LengthNoErrorFunc
+func FaultyLengthNoErrorAsLengthNoErrorFunc() LengthNoErrorFunc {
+	return func(a0 context.Context, a1 string) (r0 int) {
+		s0 := FaultyLengthNoError(a0, a1)
+		if v, ok := s0.(int); ok {
+			r0 = v
+		} else {
+			panic(funconv.NewRetVarError(0, fmt.Errorf("%T cannot be converted to %s", s0, "int")))
+		}
+		return
+	}
+}
+
+// DescribeAsStringerFunc wraps Describe as StringerFunc
+func DescribeAsStringerFunc() StringerFunc {
+	return func(a0 fmt.Stringer) (r0 string) {
+		b0, ok := a0.(stringer)
+		if !ok {
+			panic(funconv.NewArgumentError(0, fmt.Errorf("%T cannot be converted to %s", a0, "gentest.stringer")))
+		}
+		s0 := Describe(b0)
+		r0 = s0
+		return
+	}
+}
+
+// DescribeStringerAsFmtStringerFunc wraps DescribeStringer as FmtStringerFunc
+func DescribeStringerAsFmtStringerFunc() FmtStringerFunc {
+	return func(a0 stringer) (r0 string) {
+		s0 := DescribeStringer(a0)
+		r0 = s0
+		return
+	}
+}
+
+// CountNamesAsCountEndpoint wraps CountNames as CountEndpoint
+func CountNamesAsCountEndpoint() CountEndpoint {
+	return func(a0 context.Context, a1 ...string) (r0 interface{}, r1 error) {
+		s0, s1 := CountNames(a0, a1...)
+		r0 = s0
+		r1 = s1
+		return
+	}
+}
+
+// CountNamesAsCountSliceEndpoint wraps CountNames as CountSliceEndpoint
+func CountNamesAsCountSliceEndpoint() CountSliceEndpoint {
+	return func(a0 context.Context, a1 []string) (r0 interface{}, r1 error) {
+		s0, s1 := CountNames(a0, a1...)
+		r0 = s0
+		r1 = s1
+		return
+	}
+}
+
+// CountAllAsEndpoint wraps CountAll as Endpoint
+func CountAllAsEndpoint() Endpoint {
+	return func(a0 context.Context, a1 interface{}) (r0 interface{}, r1 error) {
+		b1, ok := a1.([]string)
+		if !ok && a1 != nil {
+			r1 = funconv.NewArgumentError(1, fmt.Errorf("%T cannot be converted to %s", a1, "[]string"))
+			return
+		}
+		s0, s1 := CountAll(a0, b1)
+		r0 = s0
+		r1 = s1
+		return
+	}
+}
+
+// SplitNamesAsNamesFunc wraps SplitNames as NamesFunc
+func SplitNamesAsNamesFunc() NamesFunc {
+	return func(a0 context.Context, a1 string) (r0 []string) {
+		s0 := SplitNames(a0, a1)
+		if v, ok := s0.([]string); ok || s0 == nil {
+			r0 = v
+		} else {
+			panic(funconv.NewRetVarError(0, fmt.Errorf("%T cannot be converted to %s", s0, "[]string")))
+		}
+		return
+	}
+}
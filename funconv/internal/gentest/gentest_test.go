@@ -0,0 +1,180 @@
+package gentest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-midway/midway/funconv"
+)
+
+// call calls fn with args, returning the results and the recovered panic.
+// The variadic arguments of fn are given as a slice.
+func call(fn interface{}, args ...interface{}) (results []interface{}, recovered interface{}) {
+	defer func() {
+		recovered = recover()
+	}()
+	fnVal := reflect.ValueOf(fn)
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		if arg == nil {
+			in[i] = reflect.Zero(fnVal.Type().In(i))
+			continue
+		}
+		in[i] = reflect.ValueOf(arg)
+	}
+	call := fnVal.Call
+	if fnVal.Type().IsVariadic() {
+		call = fnVal.CallSlice
+	}
+	for _, out := range call(in) {
+		results = append(results, out.Interface())
+	}
+	return
+}
+
+// describe formats values, with errors compared by type and message
+func describe(values ...interface{}) string {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		if err, ok := value.(error); ok {
+			strs[i] = fmt.Sprintf("%T(%s)", err, err.Error())
+			continue
+		}
+		strs[i] = fmt.Sprintf("%#v", value)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// TestGenerated compares the generated wrappers with the ones of
+// funconv.WrapAs, on the cases of funconv's wrap_test
+func TestGenerated(t *testing.T) {
+	var (
+		lengthFunc          LengthFunc
+		interfaceLengthFunc InterfaceLengthFunc
+		checkFunc           CheckFunc
+		endpoint            Endpoint
+		lengthEndpoint      LengthEndpoint
+		lengthNoErrorFunc   LengthNoErrorFunc
+		stringerFunc        StringerFunc
+		fmtStringerFunc     FmtStringerFunc
+		countEndpoint       CountEndpoint
+		countSliceEndpoint  CountSliceEndpoint
+		countAllEndpoint    Endpoint
+		namesFunc           NamesFunc
+	)
+
+	tests := []struct {
+		name      string
+		src       interface{}
+		dest      interface{}
+		generated interface{}
+		args      [][]interface{}
+	}{
+		{
+			name:      "passthrough",
+			src:       Length,
+			dest:      &lengthFunc,
+			generated: LengthAsLengthFunc(),
+			args:      [][]interface{}{{"hello"}},
+		},
+		{
+			name:      "interfaceIn",
+			src:       Length,
+			dest:      &interfaceLengthFunc,
+			generated: LengthAsInterfaceLengthFunc(),
+			args:      [][]interface{}{{"hello"}, {123}},
+		},
+		{
+			name:      "errorOut",
+			src:       Check,
+			dest:      &checkFunc,
+			generated: CheckAsCheckFunc(),
+			args:      [][]interface{}{{"hello"}, {""}},
+		},
+		{
+			name:      "endpoint",
+			src:       ContextLength,
+			dest:      &endpoint,
+			generated: ContextLengthAsEndpoint(),
+			args:      [][]interface{}{{nil, "hello world"}, {nil, 123}, {nil, nil}},
+		},
+		{
+			name:      "endpointOutputCastingError",
+			src:       FaultyLength,
+			dest:      &lengthEndpoint,
+			generated: FaultyLengthAsLengthEndpoint(),
+			args:      [][]interface{}{{nil, "hello"}},
+		},
+		{
+			name:      "endpointPanic",
+			src:       FaultyLengthNoError,
+			dest:      &lengthNoErrorFunc,
+			generated: FaultyLengthNoErrorAsLengthNoErrorFunc(),
+			args:      [][]interface{}{{nil, "hello"}},
+		},
+		{
+			name:      "stringer to fmt.Stringer",
+			src:       Describe,
+			dest:      &stringerFunc,
+			generated: DescribeAsStringerFunc(),
+			args:      [][]interface{}{{stringer(1)}},
+		},
+		{
+			name:      "fmt.Stringer to stringer",
+			src:       DescribeStringer,
+			dest:      &fmtStringerFunc,
+			generated: DescribeStringerAsFmtStringerFunc(),
+			args:      [][]interface{}{{stringer(1)}},
+		},
+		{
+			name:      "variadic",
+			src:       CountNames,
+			dest:      &countEndpoint,
+			generated: CountNamesAsCountEndpoint(),
+			args:      [][]interface{}{{context.Background(), []string{"a", "b"}}},
+		},
+		{
+			name:      "variadic to slice",
+			src:       CountNames,
+			dest:      &countSliceEndpoint,
+			generated: CountNamesAsCountSliceEndpoint(),
+			args:      [][]interface{}{{context.Background(), []string{"a", "b", "c"}}, {nil, nil}},
+		},
+		{
+			name:      "nil to slice argument",
+			src:       CountAll,
+			dest:      &countAllEndpoint,
+			generated: CountAllAsEndpoint(),
+			args:      [][]interface{}{{nil, []string{"a", "b"}}, {nil, nil}, {nil, "a"}},
+		},
+		{
+			name:      "nil to slice return variable",
+			src:       SplitNames,
+			dest:      &namesFunc,
+			generated: SplitNamesAsNamesFunc(),
+			args:      [][]interface{}{{nil, "a,b"}, {nil, ""}},
+		},
+	}
+
+	for _, test := range tests {
+		if err := funconv.WrapAs(test.src, test.dest); err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err.Error())
+			continue
+		}
+		reflective := reflect.ValueOf(test.dest).Elem().Interface()
+
+		for _, args := range test.args {
+			wantOut, wantPanic := call(reflective, args...)
+			haveOut, havePanic := call(test.generated, args...)
+			if want, have := describe(wantOut...), describe(haveOut...); want != have {
+				t.Errorf("%s %#v: expected %s, got %s", test.name, args, want, have)
+			}
+			if want, have := describe(wantPanic), describe(havePanic); want != have {
+				t.Errorf("%s %#v: expected panic %s, got %s", test.name, args, want, have)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package funconv
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/transport"
+	httptransport "github.com/go-kit/kit/transport/http"
+)
+
+// Endpoint wraps fn, of the form func(context.Context, Req) (Resp, error),
+// as a go-kit endpoint.Endpoint
+func Endpoint(fn interface{}, opts ...Option) (ep endpoint.Endpoint, err error) {
+	wrapped, _, err := wrapEndpoint(fn, opts)
+	if err != nil {
+		return
+	}
+	ep = wrapped
+	return
+}
+
+// RequestDecoder returns the httptransport.DecodeRequestFunc which decodes
+// requests into the Req of fn, as Handler does
+func RequestDecoder(fn interface{}, opts ...Option) (decode httptransport.DecodeRequestFunc, err error) {
+	_, reqType, err := wrapEndpoint(fn, opts)
+	if err != nil {
+		return
+	}
+	dec, err := newRequestDecoder(reqType, opts)
+	if err != nil {
+		return
+	}
+	decode = func(ctx context.Context, r *http.Request) (interface{}, error) {
+		return dec.decode(r)
+	}
+	return
+}
+
+// EncodeJSONError is the httptransport.ErrorEncoder which writes errors
+// as Handler does
+func EncodeJSONError(ctx context.Context, err error, w http.ResponseWriter) {
+	code, msg := errorStatus(err)
+	writeJSON(w, code, errorResponse{Error: msg})
+}
+
+// logErrorHandler logs the errors of *httptransport.Server as Handler does
+var logErrorHandler = transport.ErrorHandlerFunc(func(ctx context.Context, err error) {
+	code, _ := errorStatus(err)
+	logError(ctx, code, err)
+})
+
+// NewServer serves fn, of the form func(context.Context, Req) (Resp, error),
+// as a go-kit *httptransport.Server. Requests are decoded by RequestDecoder,
+// responses are encoded by httptransport.EncodeJSONResponse and errors are
+// encoded by EncodeJSONError and logged with the loggers of logcontext.
+// The serverOpts are applied after, so they override, the defaults. The
+// opts configure the conversions of fn.
+func NewServer(fn interface{}, serverOpts []httptransport.ServerOption, opts ...Option) (server *httptransport.Server, err error) {
+	ep, err := Endpoint(fn, opts...)
+	if err != nil {
+		return
+	}
+	decode, err := RequestDecoder(fn, opts...)
+	if err != nil {
+		return
+	}
+
+	serverOpts = append([]httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(EncodeJSONError),
+		httptransport.ServerErrorHandler(logErrorHandler),
+	}, serverOpts...)
+	server = httptransport.NewServer(ep, decode, httptransport.EncodeJSONResponse, serverOpts...)
+	return
+}
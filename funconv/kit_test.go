@@ -0,0 +1,101 @@
+package funconv_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/go-midway/midway/funconv"
+	"github.com/go-midway/midway/logcontext"
+)
+
+func TestEndpoint(t *testing.T) {
+	ep, err := funconv.Endpoint(createNote)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	resp, err := ep(context.Background(), noteRequest{UserID: 1, Title: "hello"})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := (noteResponse{Note: `1 "hello" false  0s`}), resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, err = ep(context.Background(), "not a request")
+	if _, ok := err.(*funconv.ArgumentError); !ok {
+		t.Errorf("expected *funconv.ArgumentError, got %#v", err)
+	}
+
+	_, err = funconv.Endpoint(func(req string) string { return req })
+	if !errors.Is(err, funconv.ErrInvalidFunc) {
+		t.Errorf("expected ErrInvalidFunc, got %#v", err)
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	server, err := funconv.NewServer(createNote, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	errs := &bytes.Buffer{}
+	mux := http.NewServeMux()
+	mux.Handle("POST /users/{id}/notes", server)
+	handler := logcontext.ProvideLoggers(kitlog.NewNopLogger(), kitlog.NewLogfmtLogger(errs))(mux)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/users/42/notes?tag=a",
+		strings.NewReader(`{"title": "hello"}`)))
+	if want, have := http.StatusCreated, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := `{"note":"42 \"hello\" false a 0s"}`+"\n", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// errors are encoded as Handler does
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/users/404/notes", nil))
+	if want, have := http.StatusNotFound, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := `{"error":"user 404 not found"}`+"\n", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/users/500/notes", nil))
+	if want, have := `{"error":"Internal Server Error"}`+"\n", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want := `at=error status=500 err="database is down"`; !strings.Contains(errs.String(), want) {
+		t.Errorf("expected log to contain %#v, got %#v", want, errs.String())
+	}
+}
+
+func TestNewServer_serverOptions(t *testing.T) {
+	server, err := funconv.NewServer(createNote, []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(func(ctx context.Context, err error, w http.ResponseWriter) {
+			w.WriteHeader(http.StatusTeapot)
+		}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	mux := http.NewServeMux()
+	mux.Handle("POST /users/{id}/notes", server)
+	handler := logcontext.ProvideLoggers(kitlog.NewNopLogger(), kitlog.NewNopLogger())(mux)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/users/404/notes", nil))
+	if want, have := http.StatusTeapot, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
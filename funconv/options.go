@@ -0,0 +1,86 @@
+package funconv
+
+import "reflect"
+
+// Rule returns the converter of a value of type from into type to,
+// or nil if the rule does not apply to the types
+type Rule func(from, to reflect.Type) Converter
+
+// config is the configuration set by Option
+type config struct {
+	registry *Registry
+	rules    []Rule
+
+	recoverPanics bool
+	onError       func(err error)
+
+	bindings []binding
+	order    []int
+
+	providers []interface{}
+
+	validate bool
+}
+
+// Option configures MapConverter and WrapAs
+type Option func(*config)
+
+func newConfig(opts []Option) (c *config) {
+	c = &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return
+}
+
+// mapper returns the mapper for the configuration
+func (c *config) mapper() *mapper {
+	registry := c.registry
+	if registry == nil {
+		registry = DefaultRegistry
+	}
+	if len(c.rules) == 0 {
+		return registry.mapper()
+	}
+	return newMapper(registry.rules(), c.rules)
+}
+
+// mapperOf returns the mapper for opts, without allocating the
+// configuration if there is no option
+func mapperOf(opts []Option) *mapper {
+	if len(opts) == 0 {
+		return DefaultRegistry.mapper()
+	}
+	return newConfig(opts).mapper()
+}
+
+// WithRules adds rules to be consulted, in order, when types are not
+// identical, assignable or implementing the interface. They take
+// precedence over the rules between pointers, containers and structs,
+// and apply to their elements and fields as well.
+//
+// The converters are not cached across calls, so hot paths should
+// wrap their functions once.
+func WithRules(rules ...Rule) Option {
+	return func(c *config) {
+		c.rules = append(c.rules, rules...)
+	}
+}
+
+// RecoverPanics makes the wrapper of WrapAs recover panics, in converting
+// values or in srcFunc, as *PanicError. Like conversion errors, they are
+// returned if the wrapper has an error return variable. Otherwise they
+// are passed to the OnError callback, or panicked again as *PanicError.
+func RecoverPanics() Option {
+	return func(c *config) {
+		c.recoverPanics = true
+	}
+}
+
+// OnError makes the wrapper of WrapAs without error return variable call
+// fn with the errors, instead of panicking, and return zero values
+func OnError(fn func(err error)) Option {
+	return func(c *config) {
+		c.onError = fn
+	}
+}
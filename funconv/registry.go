@@ -0,0 +1,93 @@
+package funconv
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// registryEntry is a rule registered with priority
+type registryEntry struct {
+	rule     Rule
+	priority int
+}
+
+// Registry holds user registered converters, which MapConverter and
+// WrapAs consult before the built-in rules.
+type Registry struct {
+	mu      sync.RWMutex
+	entries []registryEntry
+
+	// m is loaded without mu, as it is consulted for every conversion
+	m atomic.Pointer[mapper]
+}
+
+// NewRegistry returns an empty *Registry
+func NewRegistry() *Registry {
+	reg := &Registry{}
+	reg.m.Store(newMapper(nil, nil))
+	return reg
+}
+
+// DefaultRegistry is the registry consulted unless WithRegistry is given
+var DefaultRegistry = NewRegistry()
+
+// Register registers conv to convert values of type from into type to.
+// If more than one converter applies to a pair of types, the one of
+// the highest priority is used, or the earliest registered if equal.
+func (reg *Registry) Register(from, to reflect.Type, conv Converter, priority int) {
+	reg.RegisterRule(func(ruleFrom, ruleTo reflect.Type) Converter {
+		if ruleFrom == from && ruleTo == to {
+			return conv
+		}
+		return nil
+	}, priority)
+}
+
+// RegisterRule registers a rule which may apply to many pairs of types
+// (e.g. CheckedNumbers), with the same priority order as Register.
+func (reg *Registry) RegisterRule(rule Rule, priority int) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.entries = append(reg.entries, registryEntry{
+		rule:     rule,
+		priority: priority,
+	})
+	sort.SliceStable(reg.entries, func(i, j int) bool {
+		return reg.entries[i].priority > reg.entries[j].priority
+	})
+
+	// converters mapped before are stale
+	reg.m.Store(newMapper(reg.rulesLocked(), nil))
+}
+
+func (reg *Registry) rulesLocked() (rules []Rule) {
+	rules = make([]Rule, len(reg.entries))
+	for i := range reg.entries {
+		rules[i] = reg.entries[i].rule
+	}
+	return
+}
+
+// rules returns the registered rules in priority order
+func (reg *Registry) rules() []Rule {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.rulesLocked()
+}
+
+// mapper returns the mapper, which caches converters, of the
+// registered rules
+func (reg *Registry) mapper() *mapper {
+	return reg.m.Load()
+}
+
+// WithRegistry makes MapConverter or WrapAs consult reg instead of
+// DefaultRegistry
+func WithRegistry(reg *Registry) Option {
+	return func(c *config) {
+		c.registry = reg
+	}
+}
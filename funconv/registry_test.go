@@ -0,0 +1,196 @@
+package funconv_test
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-midway/midway/funconv"
+)
+
+// money is an amount in cents
+type money int64
+
+func parseMoney(in reflect.Value) (out reflect.Value, err error) {
+	amount, err := strconv.ParseFloat(in.String(), 64)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("%q is not an amount", in.String())
+	}
+	return reflect.ValueOf(money(amount*100 + 0.5)), nil
+}
+
+// tags is a sorted set of tags
+type tags []string
+
+func sortTags(in reflect.Value) (out reflect.Value, err error) {
+	sorted := append(tags(nil), in.Interface().([]string)...)
+	sort.Strings(sorted)
+	return reflect.ValueOf(sorted), nil
+}
+
+var (
+	stringType  = reflect.TypeOf("")
+	moneyType   = reflect.TypeOf(money(0))
+	stringsType = reflect.TypeOf([]string(nil))
+	tagsType    = reflect.TypeOf(tags(nil))
+)
+
+func TestRegistry_Register(t *testing.T) {
+	reg := funconv.NewRegistry()
+	reg.Register(stringType, moneyType, parseMoney, 0)
+
+	var pay func(string) (money, error)
+	err := funconv.WrapAs(func(amount money) (money, error) {
+		return amount, nil
+	}, &pay, funconv.WithRegistry(reg))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	amount, err := pay("12.34")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := money(1234), amount; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, err = pay("twelve")
+	if _, ok := err.(*funconv.ArgumentError); !ok {
+		t.Errorf("expected *funconv.ArgumentError, got %#v", err)
+	}
+	if want, have := `argument 1, "twelve" is not an amount`, fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestRegistry_fields(t *testing.T) {
+	type rawOrder struct {
+		Item  string
+		Price string
+	}
+	type order struct {
+		Item  string
+		Price money
+	}
+
+	reg := funconv.NewRegistry()
+	reg.Register(stringType, moneyType, parseMoney, 0)
+
+	var place func(rawOrder) (string, error)
+	err := funconv.WrapAs(func(o order) (string, error) {
+		return fmt.Sprintf("%s for %d", o.Item, o.Price), nil
+	}, &place, funconv.WithRegistry(reg))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	resp, err := place(rawOrder{Item: "book", Price: "9.99"})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := "book for 999", resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestRegistry_priority(t *testing.T) {
+	reg := funconv.NewRegistry()
+	reg.Register(stringType, moneyType, func(in reflect.Value) (reflect.Value, error) {
+		return reflect.ValueOf(money(1)), nil
+	}, 0)
+	reg.Register(stringType, moneyType, func(in reflect.Value) (reflect.Value, error) {
+		return reflect.ValueOf(money(2)), nil
+	}, 10)
+	reg.Register(stringType, moneyType, func(in reflect.Value) (reflect.Value, error) {
+		return reflect.ValueOf(money(3)), nil
+	}, 10)
+
+	converters, err := funconv.MapConverter(
+		[]reflect.Type{stringType},
+		[]reflect.Type{moneyType},
+		funconv.WithRegistry(reg),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	out, err := converters[0](reflect.ValueOf("0"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	// highest priority, earliest registered
+	if want, have := money(2), out.Interface(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestRegistry_precedence(t *testing.T) {
+	in := []string{"b", "c", "a"}
+
+	// []string is assignable to tags, so it is used as is by default
+	var plain func([]string) (tags, error)
+	err := funconv.WrapAs(func(t tags) (tags, error) { return t, nil }, &plain)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	out, _ := plain(in)
+	if want, have := "b,c,a", strings.Join(out, ","); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// the registered converter is consulted first
+	reg := funconv.NewRegistry()
+	reg.Register(stringsType, tagsType, sortTags, 0)
+
+	var sorted func([]string) (tags, error)
+	err = funconv.WrapAs(func(t tags) (tags, error) { return t, nil }, &sorted, funconv.WithRegistry(reg))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	out, _ = sorted(in)
+	if want, have := "a,b,c", strings.Join(out, ","); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestRegistry_isolation(t *testing.T) {
+	// registered to another registry, not DefaultRegistry
+	reg := funconv.NewRegistry()
+	reg.Register(stringType, moneyType, parseMoney, 0)
+
+	_, err := funconv.MapConverter(
+		[]reflect.Type{stringType},
+		[]reflect.Type{moneyType},
+	)
+	if err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestRegistry_RegisterRule(t *testing.T) {
+	reg := funconv.NewRegistry()
+	inTypes := []reflect.Type{stringType}
+	outTypes := []reflect.Type{reflect.TypeOf(0)}
+
+	if _, err := funconv.MapConverter(inTypes, outTypes, funconv.WithRegistry(reg)); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+
+	// converters mapped before registration are not reused
+	reg.RegisterRule(funconv.ParseStrings, 0)
+	converters, err := funconv.MapConverter(inTypes, outTypes, funconv.WithRegistry(reg))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	out, err := converters[0](reflect.ValueOf("42"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if want, have := 42, out.Interface(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
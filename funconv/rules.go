@@ -0,0 +1,266 @@
+package funconv
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func isInt(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUint(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uintptr
+}
+
+func isFloat(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return isInt(kind) || isUint(kind) || isFloat(kind)
+}
+
+// CheckedNumbers is a Rule to convert between integer and floating point
+// types, which fails on overflow, NaN, infinity, or float with fraction
+// converted to integer, instead of truncating the value.
+func CheckedNumbers(from, to reflect.Type) Converter {
+	if !isNumber(from.Kind()) || !isNumber(to.Kind()) {
+		return nil
+	}
+	return func(src reflect.Value) (dest reflect.Value, err error) {
+		dest = reflect.New(to).Elem()
+		switch kind := src.Kind(); {
+		case isInt(kind):
+			err = setInt(dest, src.Int())
+		case isUint(kind):
+			err = setUint(dest, src.Uint())
+		default:
+			err = setFloat(dest, src.Float())
+		}
+		return
+	}
+}
+
+func overflowError(v interface{}, typ reflect.Type) error {
+	return fmt.Errorf("%v overflows %s", v, typ.String())
+}
+
+func setInt(dest reflect.Value, v int64) error {
+	switch kind := dest.Kind(); {
+	case isInt(kind):
+		if dest.OverflowInt(v) {
+			return overflowError(v, dest.Type())
+		}
+		dest.SetInt(v)
+	case isUint(kind):
+		if v < 0 || dest.OverflowUint(uint64(v)) {
+			return overflowError(v, dest.Type())
+		}
+		dest.SetUint(uint64(v))
+	default:
+		dest.SetFloat(float64(v))
+	}
+	return nil
+}
+
+func setUint(dest reflect.Value, v uint64) error {
+	switch kind := dest.Kind(); {
+	case isInt(kind):
+		if v > math.MaxInt64 || dest.OverflowInt(int64(v)) {
+			return overflowError(v, dest.Type())
+		}
+		dest.SetInt(int64(v))
+	case isUint(kind):
+		if dest.OverflowUint(v) {
+			return overflowError(v, dest.Type())
+		}
+		dest.SetUint(v)
+	default:
+		dest.SetFloat(float64(v))
+	}
+	return nil
+}
+
+func setFloat(dest reflect.Value, v float64) error {
+	if isFloat(dest.Kind()) {
+		if !math.IsInf(v, 0) && !math.IsNaN(v) && dest.OverflowFloat(v) {
+			return overflowError(v, dest.Type())
+		}
+		dest.SetFloat(v)
+		return nil
+	}
+
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return fmt.Errorf("%v cannot be converted to %s", v, dest.Type().String())
+	}
+	if v != math.Trunc(v) {
+		return fmt.Errorf("%v cannot be converted to %s without truncation", v, dest.Type().String())
+	}
+	if isUint(dest.Kind()) {
+		if v < 0 || v >= math.MaxUint64 {
+			return overflowError(v, dest.Type())
+		}
+		return setUint(dest, uint64(v))
+	}
+	if v < math.MinInt64 || v >= math.MaxInt64 {
+		return overflowError(v, dest.Type())
+	}
+	return setInt(dest, int64(v))
+}
+
+// parseError formats a strconv or time parse error
+func parseError(s string, typ reflect.Type, err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		err = numErr.Err
+	}
+	return fmt.Errorf("%q cannot be parsed as %s: %w", s, typ.String(), err)
+}
+
+// ParseStrings is a Rule to parse strings into integer, floating point,
+// bool, time.Duration and time.Time (RFC 3339) types, and to format
+// them as strings.
+func ParseStrings(from, to reflect.Type) Converter {
+	switch {
+	case from.Kind() == reflect.String && to.Kind() != reflect.String:
+		return parseString(to)
+	case to.Kind() == reflect.String && from.Kind() != reflect.String:
+		return formatString(from, to)
+	}
+	return nil
+}
+
+func parseString(to reflect.Type) Converter {
+	var parse func(s string, dest reflect.Value) error
+	switch kind := to.Kind(); {
+	case to == durationType:
+		parse = func(s string, dest reflect.Value) error {
+			d, err := time.ParseDuration(s)
+			dest.SetInt(int64(d))
+			return err
+		}
+	case to == timeType:
+		parse = func(s string, dest reflect.Value) error {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			dest.Set(reflect.ValueOf(t))
+			return err
+		}
+	case isInt(kind):
+		parse = func(s string, dest reflect.Value) error {
+			v, err := strconv.ParseInt(s, 10, to.Bits())
+			dest.SetInt(v)
+			return err
+		}
+	case isUint(kind):
+		parse = func(s string, dest reflect.Value) error {
+			v, err := strconv.ParseUint(s, 10, to.Bits())
+			dest.SetUint(v)
+			return err
+		}
+	case isFloat(kind):
+		parse = func(s string, dest reflect.Value) error {
+			v, err := strconv.ParseFloat(s, to.Bits())
+			dest.SetFloat(v)
+			return err
+		}
+	case kind == reflect.Bool:
+		parse = func(s string, dest reflect.Value) error {
+			v, err := strconv.ParseBool(s)
+			dest.SetBool(v)
+			return err
+		}
+	default:
+		return nil
+	}
+	return func(src reflect.Value) (dest reflect.Value, err error) {
+		dest = reflect.New(to).Elem()
+		if err = parse(src.String(), dest); err != nil {
+			err = parseError(src.String(), to, err)
+		}
+		return
+	}
+}
+
+func formatString(from, to reflect.Type) Converter {
+	var format func(src reflect.Value) string
+	switch kind := from.Kind(); {
+	case from == durationType:
+		format = func(src reflect.Value) string {
+			return time.Duration(src.Int()).String()
+		}
+	case from == timeType:
+		format = func(src reflect.Value) string {
+			return src.Interface().(time.Time).Format(time.RFC3339Nano)
+		}
+	case isInt(kind):
+		format = func(src reflect.Value) string {
+			return strconv.FormatInt(src.Int(), 10)
+		}
+	case isUint(kind):
+		format = func(src reflect.Value) string {
+			return strconv.FormatUint(src.Uint(), 10)
+		}
+	case isFloat(kind):
+		format = func(src reflect.Value) string {
+			return strconv.FormatFloat(src.Float(), 'g', -1, from.Bits())
+		}
+	case kind == reflect.Bool:
+		format = func(src reflect.Value) string {
+			return strconv.FormatBool(src.Bool())
+		}
+	default:
+		return nil
+	}
+	return func(src reflect.Value) (reflect.Value, error) {
+		return reflect.ValueOf(format(src)).Convert(to), nil
+	}
+}
+
+func isText(typ reflect.Type) bool {
+	return typ.Kind() == reflect.String ||
+		(typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8)
+}
+
+// TextMarshaling is a Rule to convert strings or []byte into types
+// implementing encoding.TextUnmarshaler, and types implementing
+// encoding.TextMarshaler into strings or []byte.
+func TextMarshaling(from, to reflect.Type) Converter {
+	switch {
+	case isText(from) && reflect.PtrTo(to).Implements(textUnmarshalerType):
+		return func(src reflect.Value) (dest reflect.Value, err error) {
+			ptr := reflect.New(to)
+			text := src.Convert(reflect.TypeOf([]byte(nil))).Bytes()
+			if err = ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText(text); err != nil {
+				err = fmt.Errorf("%q cannot be unmarshaled as %s: %w", text, to.String(), err)
+				return
+			}
+			return ptr.Elem(), nil
+		}
+	case isText(to) && from.Implements(textMarshalerType):
+		return func(src reflect.Value) (dest reflect.Value, err error) {
+			if src.Kind() == reflect.Ptr && src.IsNil() {
+				return reflect.Zero(to), nil
+			}
+			text, err := src.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				err = fmt.Errorf("%s cannot be marshaled as text: %w", from.String(), err)
+				return
+			}
+			return reflect.ValueOf(text).Convert(to), nil
+		}
+	}
+	return nil
+}
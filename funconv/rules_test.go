@@ -0,0 +1,180 @@
+package funconv_test
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-midway/midway/funconv"
+)
+
+// convertWith converts v into the type of want with the rules
+func convertWith(rule funconv.Rule, v interface{}, want interface{}) (interface{}, error) {
+	conv := rule(reflect.TypeOf(v), reflect.TypeOf(want))
+	if conv == nil {
+		return nil, fmt.Errorf("rule not applied")
+	}
+	out, err := conv(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	return out.Interface(), nil
+}
+
+func TestCheckedNumbers(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want interface{}
+		err  string
+	}{
+		{in: int64(127), want: int8(127)},
+		{in: int64(128), want: int8(0), err: "128 overflows int8"},
+		{in: int64(-1), want: uint(0), err: "-1 overflows uint"},
+		{in: uint64(math.MaxUint64), want: int64(0), err: "18446744073709551615 overflows int64"},
+		{in: uint8(255), want: int16(255)},
+		{in: 3.0, want: int(3)},
+		{in: 3.5, want: int(0), err: "3.5 cannot be converted to int without truncation"},
+		{in: math.NaN(), want: int(0), err: "NaN cannot be converted to int"},
+		{in: math.Inf(1), want: uint(0), err: "+Inf cannot be converted to uint"},
+		{in: 1e300, want: float32(0), err: "1e+300 overflows float32"},
+		{in: 300.0, want: uint8(0), err: "300 overflows uint8"},
+		{in: int32(-5), want: float32(-5)},
+		{in: time.Duration(5), want: int64(5)},
+	}
+	for _, test := range tests {
+		have, err := convertWith(funconv.CheckedNumbers, test.in, test.want)
+		if test.err != "" {
+			if want, have := test.err, fmt.Sprintf("%v", err); want != have {
+				t.Errorf("%T(%v): expected error %#v, got %#v", test.in, test.in, want, have)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%T(%v): unexpected error: %s", test.in, test.in, err.Error())
+		} else if test.want != have {
+			t.Errorf("%T(%v): expected %#v, got %#v", test.in, test.in, test.want, have)
+		}
+	}
+
+	if funconv.CheckedNumbers(reflect.TypeOf(""), reflect.TypeOf(0)) != nil {
+		t.Errorf("expected rule not to apply to string")
+	}
+}
+
+func TestParseStrings(t *testing.T) {
+	ts := time.Date(2018, 5, 1, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		in   interface{}
+		want interface{}
+		err  string
+	}{
+		{in: "-42", want: int8(-42)},
+		{in: "300", want: int8(0), err: `"300" cannot be parsed as int8: value out of range`},
+		{in: "abc", want: 0, err: `"abc" cannot be parsed as int: invalid syntax`},
+		{in: "42", want: uint16(42)},
+		{in: "1.5", want: 1.5},
+		{in: "true", want: true},
+		{in: "1m30s", want: 90 * time.Second},
+		{in: "2018-05-01T12:30:00Z", want: ts},
+		{in: 42, want: "42"},
+		{in: uint8(7), want: "7"},
+		{in: 1.5, want: "1.5"},
+		{in: false, want: "false"},
+		{in: 90 * time.Second, want: "1m30s"},
+		{in: ts, want: "2018-05-01T12:30:00Z"},
+	}
+	for _, test := range tests {
+		have, err := convertWith(funconv.ParseStrings, test.in, test.want)
+		if test.err != "" {
+			if want, have := test.err, fmt.Sprintf("%v", err); want != have {
+				t.Errorf("%T(%v): expected error %#v, got %#v", test.in, test.in, want, have)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%T(%v): unexpected error: %s", test.in, test.in, err.Error())
+		} else if !reflect.DeepEqual(test.want, have) {
+			t.Errorf("%T(%v): expected %#v, got %#v", test.in, test.in, test.want, have)
+		}
+	}
+}
+
+func TestTextMarshaling(t *testing.T) {
+	ip, err := convertWith(funconv.TextMarshaling, "127.0.0.1", net.IP{})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	} else if want, have := "127.0.0.1", ip.(net.IP).String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, err = convertWith(funconv.TextMarshaling, []byte("not an ip"), net.IP{})
+	if want, have := `"not an ip" cannot be unmarshaled as net.IP: invalid IP address: not an ip`, fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	str, err := convertWith(funconv.TextMarshaling, net.IPv4(10, 0, 0, 1), "")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	} else if want, have := "10.0.0.1", str; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_withRules(t *testing.T) {
+	type query struct {
+		Page    int
+		Timeout time.Duration
+		Since   *time.Time
+	}
+	describe := func(q query) (string, error) {
+		return fmt.Sprintf("%d %s %s", q.Page, q.Timeout, q.Since.Format("2006-01-02")), nil
+	}
+	type rawQuery struct {
+		Page    string
+		Timeout string
+		Since   string
+	}
+
+	// not converted without the rules
+	var endpoint func(rawQuery) (string, error)
+	if err := funconv.WrapAs(describe, &endpoint); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+
+	err := funconv.WrapAs(describe, &endpoint, funconv.WithRules(funconv.ParseStrings))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	resp, err := endpoint(rawQuery{Page: "2", Timeout: "5s", Since: "2018-05-01T00:00:00Z"})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := "2 5s 2018-05-01", resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, err = endpoint(rawQuery{Page: "two"})
+	if _, ok := err.(*funconv.ArgumentError); !ok {
+		t.Errorf("expected *funconv.ArgumentError, got %#v", err)
+	}
+	if want, have := `argument 1, field Page, "two" cannot be parsed as int: invalid syntax`, fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// checked return variable
+	var narrow func() (int8, error)
+	err = funconv.WrapAs(func() (int, error) { return 1000, nil }, &narrow, funconv.WithRules(funconv.CheckedNumbers))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	_, err = narrow()
+	if _, ok := err.(*funconv.RetVarError); !ok {
+		t.Errorf("expected *funconv.RetVarError, got %#v", err)
+	}
+	if want, have := "return variable 1, 1000 overflows int8", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
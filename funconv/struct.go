@@ -0,0 +1,102 @@
+package funconv
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldKey returns the key to match a struct field by, which is the name
+// in the `funconv:"name"` tag or the field name. The tag may also mark the
+// field as ignored (`funconv:"-"`) or as not required (`funconv:",optional"`).
+func fieldKey(field reflect.StructField) (key string, optional, ignored bool) {
+	tag := strings.Split(field.Tag.Get("funconv"), ",")
+	if key = tag[0]; key == "-" {
+		ignored = true
+		return
+	}
+	if key == "" {
+		key = field.Name
+	}
+	for _, opt := range tag[1:] {
+		optional = optional || opt == "optional"
+	}
+	return
+}
+
+// fieldMap maps a field of the destination struct from the source struct
+type fieldMap struct {
+	name   string
+	from   int
+	to     int
+	conv   Converter
+	direct bool
+}
+
+// mapStruct maps the converter between structs field by field.
+// Every exported field of the destination needs a source field
+// of the same key, unless it is optional.
+func (m *mapper) mapStruct(fromStruct, toStruct reflect.Type) (mapped mappedConverter) {
+	srcFields := make(map[string]int)
+	for i := 0; i < fromStruct.NumField(); i++ {
+		field := fromStruct.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		if key, _, ignored := fieldKey(field); !ignored {
+			srcFields[key] = i
+		}
+	}
+
+	var fields []fieldMap
+	for i := 0; i < toStruct.NumField(); i++ {
+		field := toStruct.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		key, optional, ignored := fieldKey(field)
+		if ignored {
+			continue
+		}
+		srcIndex, found := srcFields[key]
+		if !found {
+			if optional {
+				continue
+			}
+			mapped.reason = fmt.Errorf("field %s has no source field", field.Name)
+			return
+		}
+
+		srcType := fromStruct.Field(srcIndex).Type
+		fieldMapped := m.build(srcType, field.Type)
+		if !fieldMapped.ok {
+			mapped.reason = fmt.Errorf("field %s, %s cannot be converted to %s%s",
+				field.Name, srcType.String(), field.Type.String(), reasonSuffix(fieldMapped.reason))
+			return
+		}
+		fields = append(fields, fieldMap{
+			name:   field.Name,
+			from:   srcIndex,
+			to:     i,
+			conv:   fieldMapped.conv,
+			direct: fieldMapped.direct,
+		})
+	}
+
+	mapped.ok = true
+	mapped.conv = func(src reflect.Value) (dest reflect.Value, err error) {
+		dest = reflect.New(toStruct).Elem()
+		for _, field := range fields {
+			value := src.Field(field.from)
+			if !field.direct {
+				if value, err = field.conv(value); err != nil {
+					err = fmt.Errorf("field %s, %w", field.name, err)
+					return
+				}
+			}
+			dest.Field(field.to).Set(value)
+		}
+		return
+	}
+	return
+}
@@ -0,0 +1,202 @@
+package funconv_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-midway/midway/funconv"
+)
+
+type addressDTO struct {
+	City string
+}
+
+type userDTO struct {
+	Name     string
+	Mail     string `funconv:"email"`
+	Address  addressDTO
+	Nickname interface{}
+	Internal string
+	password string
+}
+
+type address struct {
+	City string
+}
+
+type user struct {
+	Name     string
+	Email    string `funconv:"email"`
+	Address  address
+	Nickname string
+	Note     string `funconv:",optional"`
+	Internal string `funconv:"-"`
+}
+
+type node struct {
+	Value int
+	Next  *node
+}
+
+type nodeDTO struct {
+	Value interface{}
+	Next  *nodeDTO
+}
+
+func TestWrap_struct(t *testing.T) {
+	describe := func(ctx context.Context, u user) (string, error) {
+		return fmt.Sprintf("%s <%s> at %s, aka %s, %q %q",
+			u.Name, u.Email, u.Address.City, u.Nickname, u.Note, u.Internal), nil
+	}
+	var endpoint func(ctx context.Context, u userDTO) (string, error)
+	if err := funconv.WrapAs(describe, &endpoint); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	resp, err := endpoint(nil, userDTO{
+		Name:     "John",
+		Mail:     "john@example.com",
+		Address:  addressDTO{City: "Hong Kong"},
+		Nickname: "johnny",
+		Internal: "secret",
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := `John <john@example.com> at Hong Kong, aka johnny, "" ""`, resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// field conversion error
+	_, err = endpoint(nil, userDTO{Nickname: 123})
+	if want, have := "argument 2, field Nickname, int cannot be converted to string", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_structPointer(t *testing.T) {
+	city := func(a address) string {
+		return a.City
+	}
+	cityPtr := func(a *address) string {
+		if a == nil {
+			return "nowhere"
+		}
+		return a.City
+	}
+
+	var fromPtr func(*addressDTO) string
+	var toPtr func(addressDTO) string
+	var ptrToPtr func(*addressDTO) string
+
+	if err := funconv.WrapAs(city, &fromPtr); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if err := funconv.WrapAs(cityPtr, &toPtr); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if err := funconv.WrapAs(cityPtr, &ptrToPtr); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	dto := addressDTO{City: "Taipei"}
+	for name, test := range map[string]struct {
+		want string
+		have string
+	}{
+		"*struct to struct":      {"Taipei", fromPtr(&dto)},
+		"nil *struct to struct":  {"", fromPtr(nil)},
+		"struct to *struct":      {"Taipei", toPtr(dto)},
+		"*struct to *struct":     {"Taipei", ptrToPtr(&dto)},
+		"nil *struct to *struct": {"nowhere", ptrToPtr(nil)},
+	} {
+		if test.want != test.have {
+			t.Errorf("%s: expected %#v, got %#v", name, test.want, test.have)
+		}
+	}
+}
+
+func TestWrap_structRecursive(t *testing.T) {
+	sum := func(n *node) (total int, err error) {
+		for ; n != nil; n = n.Next {
+			total += n.Value
+		}
+		return
+	}
+	var endpoint func(*nodeDTO) (int, error)
+	if err := funconv.WrapAs(sum, &endpoint); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	total, err := endpoint(&nodeDTO{Value: 1, Next: &nodeDTO{Value: 2, Next: &nodeDTO{Value: 3}}})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := 6, total; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, err = endpoint(&nodeDTO{Value: 1, Next: &nodeDTO{Value: "2"}})
+	if want, have := "argument 1, field Next, field Value, string cannot be converted to int", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_structUnmapped(t *testing.T) {
+	type target struct {
+		Name  string
+		Email string
+	}
+	type source struct {
+		Name string
+	}
+	type badSource struct {
+		Name  int
+		Email string
+	}
+
+	var endpoint func(source)
+	err := funconv.WrapAs(func(target) {}, &endpoint)
+	if want, have := "argument 1, funconv_test.source cannot be converted funconv_test.target: field Email has no source field", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	var badEndpoint func(badSource)
+	err = funconv.WrapAs(func(target) {}, &badEndpoint)
+	if want, have := "argument 1, funconv_test.badSource cannot be converted funconv_test.target: field Name, int cannot be converted to string", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+// listA and listB are recursive types, of which fields X mismatch
+type listA struct {
+	Next *listA
+	X    chan int
+}
+
+type listB struct {
+	Next *listB
+	X    string
+}
+
+func TestMapConverter_structRecursiveUnmapped(t *testing.T) {
+	_, err := funconv.MapConverter(
+		[]reflect.Type{reflect.TypeOf(listA{})},
+		[]reflect.Type{reflect.TypeOf(listB{})},
+	)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+
+	// the pointers were mapped while mapping the structs, and
+	// are not cached as converted
+	_, err = funconv.MapConverter(
+		[]reflect.Type{reflect.TypeOf(&listA{})},
+		[]reflect.Type{reflect.TypeOf(&listB{})},
+	)
+	if want, have := "at 0, *funconv_test.listA cannot be converted to *funconv_test.listB: pointer, funconv_test.listA cannot be converted to funconv_test.listB: field X, chan int cannot be converted to string", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
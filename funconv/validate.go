@@ -0,0 +1,376 @@
+package funconv
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validator is implemented by the arguments validating themselves
+type Validator interface {
+	Validate() error
+}
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// ValidateArgs makes the wrapper of WrapAs validate the arguments of
+// srcFunc, after conversion and before calling it. The fields of struct
+// arguments, or of structs they point to, are checked by their tags:
+//
+//	validate:"required,min=1,max=64,oneof=a b"
+//
+// where the rules are
+//
+//   - required: the field is not the zero value
+//   - omitempty: the other rules are skipped if the field is the zero value
+//   - min=n, max=n: the number, or the length of a string, slice or map,
+//     is at least or at most n
+//   - oneof=a b: the string or integer is one of the space separated values
+//
+// Fields of struct types, or pointers to structs, are checked by their
+// tags as well. Nil pointers are only checked by the required rule.
+// Arguments implementing Validator, by value or pointer receiver, are
+// then validated with it.
+//
+// The failures are returned as *ValidationError wrapped in *ArgumentError.
+// Tags of unknown rules, or rules not applying to the field types, are
+// errors of WrapAs.
+func ValidateArgs() Option {
+	return func(c *config) {
+		c.validate = true
+	}
+}
+
+// FieldError is a struct field failing a rule of its validate tag
+type FieldError struct {
+	field string
+	rule  string
+	param string
+	size  bool
+}
+
+// Field returns the name of the field. Fields of nested structs are
+// named with the path, e.g. Address.City.
+func (err FieldError) Field() string {
+	return err.field
+}
+
+// Rule returns the failed rule, e.g. required or min
+func (err FieldError) Rule() string {
+	return err.rule
+}
+
+// Param returns the parameter of the failed rule, if any
+func (err FieldError) Param() string {
+	return err.param
+}
+
+func (err FieldError) Error() string {
+	switch err.rule {
+	case "required":
+		return fmt.Sprintf("field %s is required", err.field)
+	case "min", "max":
+		bound := "at least"
+		if err.rule == "max" {
+			bound = "at most"
+		}
+		if err.size {
+			return fmt.Sprintf("field %s needs a length of %s %s", err.field, bound, err.param)
+		}
+		return fmt.Sprintf("field %s needs to be %s %s", err.field, bound, err.param)
+	}
+	return fmt.Sprintf("field %s needs to be one of %s", err.field, err.param)
+}
+
+// ValidationError is the error of an argument failing validation. It lists
+// the fields failing the rules of their tags, or wraps the error of
+// Validator.
+type ValidationError struct {
+	fields []*FieldError
+	err    error
+}
+
+// Fields returns the fields failing the rules of their tags
+func (err ValidationError) Fields() []*FieldError {
+	return err.fields
+}
+
+func (err ValidationError) Error() string {
+	if len(err.fields) == 0 {
+		return err.err.Error()
+	}
+	msgs := make([]string, len(err.fields))
+	for i, field := range err.fields {
+		msgs[i] = field.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the field errors, or the error of Validator
+func (err ValidationError) Unwrap() []error {
+	if len(err.fields) == 0 {
+		return []error{err.err}
+	}
+	unwrapped := make([]error, len(err.fields))
+	for i, field := range err.fields {
+		unwrapped[i] = field
+	}
+	return unwrapped
+}
+
+// StatusCode implements StatusCoder, so Handler responds validation
+// failures as 400 Bad Request
+func (err ValidationError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// validateRule is a rule of a validate tag
+type validateRule struct {
+	name  string
+	param string
+	size  bool
+	check func(v reflect.Value) bool
+}
+
+// fieldValidator checks a struct field by the rules of its tag
+type fieldValidator struct {
+	index     int
+	name      string
+	omitempty bool
+	required  bool
+	rules     []validateRule
+	nested    *structValidator
+}
+
+// structValidator checks the fields of a struct type
+type structValidator struct {
+	fields []fieldValidator
+}
+
+// measure returns the number to compare with the min and max rules, and
+// if it is a length, or false if the rules do not apply to the kind
+func measure(kind reflect.Kind) (fn func(v reflect.Value) float64, size, ok bool) {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) float64 { return float64(v.Int()) }, false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(v reflect.Value) float64 { return float64(v.Uint()) }, false, true
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value) float64 { return v.Float() }, false, true
+	case reflect.String:
+		return func(v reflect.Value) float64 { return float64(utf8.RuneCountInString(v.String())) }, true, true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return func(v reflect.Value) float64 { return float64(v.Len()) }, true, true
+	}
+	return nil, false, false
+}
+
+// formatOneOf returns the string to match with the oneof rule, or false
+// if the rule does not apply to the kind
+func formatOneOf(kind reflect.Kind) (fn func(v reflect.Value) string, ok bool) {
+	switch kind {
+	case reflect.String:
+		return reflect.Value.String, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) string { return strconv.FormatInt(v.Int(), 10) }, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(v reflect.Value) string { return strconv.FormatUint(v.Uint(), 10) }, true
+	}
+	return nil, false
+}
+
+// parseRule parses a rule of the validate tag of a field of typ
+func parseRule(typ reflect.Type, tagRule string) (rule validateRule, err error) {
+	rule.name, rule.param, _ = strings.Cut(tagRule, "=")
+	switch rule.name {
+	case "min", "max":
+		bound, err := strconv.ParseFloat(rule.param, 64)
+		if err != nil {
+			return rule, fmt.Errorf("invalid %s parameter %q", rule.name, rule.param)
+		}
+		fn, size, ok := measure(typ.Kind())
+		if !ok {
+			return rule, fmt.Errorf("rule %s does not apply to %s", rule.name, typ.String())
+		}
+		rule.size = size
+		if rule.name == "min" {
+			rule.check = func(v reflect.Value) bool { return fn(v) >= bound }
+		} else {
+			rule.check = func(v reflect.Value) bool { return fn(v) <= bound }
+		}
+	case "oneof":
+		fn, ok := formatOneOf(typ.Kind())
+		if !ok {
+			return rule, fmt.Errorf("rule oneof does not apply to %s", typ.String())
+		}
+		values := strings.Fields(rule.param)
+		rule.check = func(v reflect.Value) bool {
+			str := fn(v)
+			for _, value := range values {
+				if str == value {
+					return true
+				}
+			}
+			return false
+		}
+	default:
+		err = fmt.Errorf("unknown validate rule %s", rule.name)
+	}
+	return
+}
+
+// newStructValidator returns the validator of the fields of structType,
+// or nil if no field is validated. The validators in building are of the
+// struct types being built, which are reused for recursive types.
+func newStructValidator(structType reflect.Type, building map[reflect.Type]*structValidator) (sv *structValidator, err error) {
+	if sv, ok := building[structType]; ok {
+		return sv, nil
+	}
+	sv = &structValidator{}
+	building[structType] = sv
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fv := fieldValidator{index: i, name: field.Name}
+
+		// the rules apply to the values pointers point to
+		typ := field.Type
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if tag := field.Tag.Get("validate"); tag != "" {
+			for _, tagRule := range strings.Split(tag, ",") {
+				switch tagRule {
+				case "required":
+					fv.required = true
+				case "omitempty":
+					fv.omitempty = true
+				default:
+					rule, err := parseRule(typ, tagRule)
+					if err != nil {
+						return nil, fmt.Errorf("field %s, %s", field.Name, err.Error())
+					}
+					fv.rules = append(fv.rules, rule)
+				}
+			}
+		}
+		if typ.Kind() == reflect.Struct {
+			if fv.nested, err = newStructValidator(typ, building); err != nil {
+				return nil, fmt.Errorf("field %s, %s", field.Name, err.Error())
+			}
+		}
+		if fv.required || len(fv.rules) > 0 || fv.nested != nil {
+			sv.fields = append(sv.fields, fv)
+		}
+	}
+	if len(sv.fields) == 0 {
+		return nil, nil
+	}
+	return
+}
+
+// check appends the fields of v failing their rules to failed. The names
+// of the fields are prefixed with prefix.
+func (sv *structValidator) check(v reflect.Value, prefix string, failed []*FieldError) []*FieldError {
+	for _, fv := range sv.fields {
+		name := prefix + fv.name
+		value := v.Field(fv.index)
+		if value.IsZero() {
+			if fv.required {
+				failed = append(failed, &FieldError{field: name, rule: "required"})
+			}
+			if fv.required || fv.omitempty {
+				continue
+			}
+		}
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+		for _, rule := range fv.rules {
+			if !rule.check(value) {
+				failed = append(failed, &FieldError{field: name, rule: rule.name, param: rule.param, size: rule.size})
+			}
+		}
+		if fv.nested != nil {
+			failed = fv.nested.check(value, name+".", failed)
+		}
+	}
+	return failed
+}
+
+// newArgValidator returns the function validating the arguments of typ,
+// or nil if there is nothing to validate
+func newArgValidator(typ reflect.Type) (validate func(v reflect.Value) error, err error) {
+	structType := typ
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	var sv *structValidator
+	if structType.Kind() == reflect.Struct {
+		sv, err = newStructValidator(structType, make(map[reflect.Type]*structValidator))
+		if err != nil {
+			return
+		}
+	}
+	isValidator := typ.Implements(validatorType)
+	ptrValidator := !isValidator && reflect.PtrTo(typ).Implements(validatorType)
+	if sv == nil && !isValidator && !ptrValidator {
+		return
+	}
+
+	validate = func(v reflect.Value) error {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil
+		}
+		if sv != nil {
+			if failed := sv.check(reflect.Indirect(v), "", nil); len(failed) > 0 {
+				return &ValidationError{fields: failed}
+			}
+		}
+
+		// Validate of pointer receiver is called with a copy
+		var validator Validator
+		switch {
+		case isValidator:
+			validator = v.Interface().(Validator)
+		case ptrValidator:
+			ptr := reflect.New(typ)
+			ptr.Elem().Set(v)
+			validator = ptr.Interface().(Validator)
+		default:
+			return nil
+		}
+		if err := validator.Validate(); err != nil {
+			return &ValidationError{err: err}
+		}
+		return nil
+	}
+	return
+}
+
+// argValidators returns the validators of the arguments of fnType, which
+// are nil for the arguments with nothing to validate. It returns nil if
+// no argument is validated.
+func argValidators(fnType reflect.Type) (validators []func(v reflect.Value) error, err error) {
+	validators = make([]func(v reflect.Value) error, fnType.NumIn())
+	found := false
+	for i := range validators {
+		if validators[i], err = newArgValidator(fnType.In(i)); err != nil {
+			return nil, fmt.Errorf("argument %d, %s", i+1, err.Error())
+		}
+		found = found || validators[i] != nil
+	}
+	if !found {
+		validators = nil
+	}
+	return
+}
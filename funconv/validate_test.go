@@ -0,0 +1,192 @@
+package funconv_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-midway/midway/funconv"
+	"github.com/go-midway/midway/logcontext"
+)
+
+type signupAddress struct {
+	City string `validate:"required"`
+}
+
+type signupRequest struct {
+	Name     string         `json:"name" validate:"required,min=1,max=8"`
+	Age      int            `json:"age" validate:"omitempty,min=18"`
+	Plan     string         `json:"plan" validate:"oneof=free pro"`
+	Tags     []string       `json:"tags" validate:"max=2"`
+	Address  *signupAddress `json:"address"`
+	Password string         `json:"password"`
+	Confirm  string         `json:"confirm"`
+}
+
+// Validate implements funconv.Validator with pointer receiver
+func (req *signupRequest) Validate() error {
+	if req.Password != req.Confirm {
+		return errors.New("passwords do not match")
+	}
+	return nil
+}
+
+func signup(ctx context.Context, req signupRequest) (string, error) {
+	return "welcome " + req.Name, nil
+}
+
+func TestWrap_validateArgs(t *testing.T) {
+	var endpoint func(ctx context.Context, req interface{}) (interface{}, error)
+	if err := funconv.WrapAs(signup, &endpoint, funconv.ValidateArgs()); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	tests := []struct {
+		name   string
+		req    signupRequest
+		want   string
+		fields []string
+	}{
+		{
+			name: "valid",
+			req:  signupRequest{Name: "gopher", Plan: "pro", Address: &signupAddress{City: "Taipei"}},
+		},
+		{
+			name:   "required",
+			req:    signupRequest{Plan: "free"},
+			want:   "argument 2, field Name is required",
+			fields: []string{"Name"},
+		},
+		{
+			name:   "rules",
+			req:    signupRequest{Name: "long gopher", Age: 12, Plan: "gold", Tags: []string{"a", "b", "c"}},
+			want:   "argument 2, field Name needs a length of at most 8; field Age needs to be at least 18; field Plan needs to be one of free pro; field Tags needs a length of at most 2",
+			fields: []string{"Name", "Age", "Plan", "Tags"},
+		},
+		{
+			name:   "nested",
+			req:    signupRequest{Name: "gopher", Plan: "pro", Address: &signupAddress{}},
+			want:   "argument 2, field Address.City is required",
+			fields: []string{"Address.City"},
+		},
+		{
+			name: "validator",
+			req:  signupRequest{Name: "gopher", Plan: "pro", Password: "a", Confirm: "b"},
+			want: "argument 2, passwords do not match",
+		},
+	}
+	for _, test := range tests {
+		resp, err := endpoint(context.Background(), test.req)
+		if test.want == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err.Error())
+			} else if want, have := "welcome gopher", resp; want != have {
+				t.Errorf("%s: expected %#v, got %#v", test.name, want, have)
+			}
+			continue
+		}
+		if want, have := test.want, fmt.Sprintf("%v", err); want != have {
+			t.Errorf("%s: expected %#v, got %#v", test.name, want, have)
+		}
+
+		var validationErr *funconv.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%s: expected *funconv.ValidationError, got %#v", test.name, err)
+			continue
+		}
+		var fields []string
+		for _, field := range validationErr.Fields() {
+			fields = append(fields, field.Field())
+		}
+		if want, have := strings.Join(test.fields, ","), strings.Join(fields, ","); want != have {
+			t.Errorf("%s: expected %#v, got %#v", test.name, want, have)
+		}
+	}
+
+	// field errors are matched by errors.As
+	_, err := endpoint(context.Background(), signupRequest{Name: "gopher", Plan: "gold"})
+	var fieldErr *funconv.FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("expected *funconv.FieldError, got %#v", err)
+	}
+	if want, have := "oneof", fieldErr.Rule(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "free pro", fieldErr.Param(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_validateArgsNotEnabled(t *testing.T) {
+	var endpoint func(ctx context.Context, req interface{}) (interface{}, error)
+	if err := funconv.WrapAs(signup, &endpoint); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	resp, err := endpoint(context.Background(), signupRequest{})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := "welcome ", resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_validateArgsInvalidTags(t *testing.T) {
+	tests := []struct {
+		fn   interface{}
+		want string
+	}{
+		{
+			fn: func(req struct {
+				Name string `validate:"email"`
+			}) {
+			},
+			want: "argument 1, field Name, unknown validate rule email",
+		},
+		{
+			fn: func(req struct {
+				Name string `validate:"min=one"`
+			}) {
+			},
+			want: `argument 1, field Name, invalid min parameter "one"`,
+		},
+		{
+			fn: func(req *struct {
+				Enabled bool `validate:"oneof=true"`
+			}) {
+			},
+			want: "argument 1, field Enabled, rule oneof does not apply to bool",
+		},
+	}
+	for _, test := range tests {
+		var fn func(req interface{})
+		err := funconv.WrapAs(test.fn, &fn, funconv.ValidateArgs())
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		} else if want, have := test.want, err.Error(); want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+	}
+}
+
+func TestHandler_validateArgs(t *testing.T) {
+	handler, err := funconv.Handler(signup, funconv.ValidateArgs())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	handler = logcontext.ProvideLoggers(kitlog.NewNopLogger(), kitlog.NewNopLogger())(handler)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/signup", strings.NewReader(`{"plan": "free"}`)))
+	if want, have := http.StatusBadRequest, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := `{"error":"argument 2, field Name is required"}`+"\n", w.Body.String(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
@@ -5,71 +5,32 @@ import (
 	"reflect"
 )
 
-// Converter converts one reflect.Value into another
-type Converter func(reflect.Value) (reflect.Value, error)
-
-// Convert the src to dest, according to the reflect.Value conversion
-func (conv Converter) Convert(src interface{}) interface{} {
-	srcValue := reflect.ValueOf(src)
-	destValue, err := conv(srcValue)
-	if err != nil {
-		panic(err)
+// makePipe returns a function to convert values in place with the
+// converters, or nil if every converter is a direct map
+func makePipe(converters []Converter, direct []bool) func([]reflect.Value) error {
+	allDirect := true
+	for i := range direct {
+		allDirect = allDirect && direct[i]
 	}
-	return destValue.Interface()
-}
-
-func makePipe(converters []Converter) func([]reflect.Value) ([]reflect.Value, error) {
-	return func(in []reflect.Value) (out []reflect.Value, err error) {
-		length := len(in)
-		out = make([]reflect.Value, length)
-		for i := range in {
-			out[i], err = converters[i](in[i])
-			if err != nil {
-				err = &convertError{
+	if allDirect {
+		return nil
+	}
+	return func(values []reflect.Value) (err error) {
+		for i := range values {
+			if direct[i] {
+				continue
+			}
+			if values[i], err = converters[i](values[i]); err != nil {
+				return &convertError{
 					pos: i,
 					err: err,
 				}
-				return
 			}
 		}
 		return
 	}
 }
 
-func directMap(src reflect.Value) (reflect.Value, error) {
-	return src, nil
-}
-
-func convertToType(typ reflect.Type) Converter {
-	return func(src reflect.Value) (reflect.Value, error) {
-		return src.Convert(typ), nil
-	}
-}
-
-func reverseConvertToItfType(typ reflect.Type) Converter {
-	return func(src reflect.Value) (dest reflect.Value, err error) {
-
-		srcInner := src
-		if src.Kind() == reflect.Interface {
-			srcInner = src.Elem()
-		}
-
-		if srcInner.Type() == typ {
-			dest = srcInner
-			return
-		}
-		if srcInner.Type().AssignableTo(typ) {
-			dest = srcInner.Convert(typ)
-			return
-		}
-
-		// TODO: return as error
-		err = fmt.Errorf("%s cannot be converted to %s",
-			srcInner.Type().String(), typ.String())
-		return
-	}
-}
-
 func findLastError(typs []reflect.Type) (pos int) {
 	for pos = len(typs) - 1; pos >= 0; pos-- {
 		if typs[pos].String() == "error" {
@@ -86,10 +47,26 @@ type ArgumentError struct {
 	err error
 }
 
+// NewArgumentError returns the *ArgumentError of converting the
+// argument at pos (zero based). It is meant for generated wrappers.
+func NewArgumentError(pos int, err error) *ArgumentError {
+	return &ArgumentError{pos: pos, err: err}
+}
+
 func (err ArgumentError) Error() string {
 	return fmt.Sprintf("argument %d, %s", err.pos+1, err.err.Error())
 }
 
+// Position returns the position (zero based) of the argument
+func (err ArgumentError) Position() int {
+	return err.pos
+}
+
+// Unwrap returns the error of the conversion
+func (err ArgumentError) Unwrap() error {
+	return err.err
+}
+
 // RetVarError is the runtime error in coverting
 // arguments from wrapper function to the "real" function
 type RetVarError struct {
@@ -97,10 +74,26 @@ type RetVarError struct {
 	err error
 }
 
+// NewRetVarError returns the *RetVarError of converting the
+// return variable at pos (zero based). It is meant for generated wrappers.
+func NewRetVarError(pos int, err error) *RetVarError {
+	return &RetVarError{pos: pos, err: err}
+}
+
 func (err RetVarError) Error() string {
 	return fmt.Sprintf("return variable %d, %s", err.pos+1, err.err.Error())
 }
 
+// Position returns the position (zero based) of the return variable
+func (err RetVarError) Position() int {
+	return err.pos
+}
+
+// Unwrap returns the error of the conversion
+func (err RetVarError) Unwrap() error {
+	return err.err
+}
+
 type convertError struct {
 	pos int
 	err error
@@ -110,196 +103,300 @@ func (err convertError) Error() string {
 	return fmt.Sprintf("at %d, %s", err.pos, err.err.Error())
 }
 
-type mapConverterError struct {
-	pos  int
-	from reflect.Type
-	to   reflect.Type
-}
-
-func (err mapConverterError) Error() string {
-	return fmt.Sprintf("at %d, %s cannot be converted to %s",
-		err.pos, err.from.String(), err.to.String())
-}
-
-// MapConverter maps converter slice for converting variable inTypes into outTypes
-func MapConverter(inTypes []reflect.Type, outTypes []reflect.Type) (converters []Converter, err error) {
-	length := len(inTypes)
-	converters = make([]Converter, length)
-	for i := 0; i < length; i++ {
-		if inTypes[i] == outTypes[i] {
-			//log.Printf("pos: %d, conveter: directMap", i)
-			converters[i] = directMap
-			continue
-		}
-		if inTypes[i].AssignableTo(outTypes[i]) {
-			//log.Printf("pos: %d, conveter: convertToType", i)
-			converters[i] = convertToType(outTypes[i])
-			continue
-		}
-		if inTypes[i].Kind() == reflect.Interface && outTypes[i].Implements(inTypes[i]) {
-			//log.Printf("pos: %d, conveter: reverseConvertToItfType", i)
-			converters[i] = reverseConvertToItfType(outTypes[i])
-			continue
+// checkSignature checks if srcFuncType can be wrapped as destFuncType by
+// the numbers of arguments and return variables, and by being variadic.
+// A variadic function is wrapped as a function taking the slice as the
+// last argument, and vice versa.
+func checkSignature(srcFuncType, destFuncType reflect.Type) error {
+	if want, have := srcFuncType.NumIn(), destFuncType.NumIn(); want != have {
+		return &ArityMismatchError{src: want, dest: have}
+	}
+	if want, have := srcFuncType.NumOut(), destFuncType.NumOut(); want != have {
+		return &ArityMismatchError{ret: true, src: want, dest: have}
+	}
+	if srcVariadic := srcFuncType.IsVariadic(); srcVariadic != destFuncType.IsVariadic() {
+		other := destFuncType
+		if !srcVariadic {
+			other = srcFuncType
 		}
-		err = &mapConverterError{
-			pos:  i,
-			from: inTypes[i],
-			to:   outTypes[i],
+		if n := other.NumIn(); n == 0 || other.In(n-1).Kind() != reflect.Slice {
+			return &VariadicMismatchError{srcVariadic: srcVariadic}
 		}
 	}
-	return
+	return nil
 }
 
 // WrapAs takes a funciton value (srcFunc), wrap it properly with type conversions
 // then set it to function variable pointer (destFunc)
-func WrapAs(srcFunc, destFunc interface{}) (err error) {
+func WrapAs(srcFunc, destFunc interface{}, opts ...Option) (err error) {
 
 	//
 	// validate arguments type
 	//
 
 	if srcFunc == nil {
-		err = fmt.Errorf("srcFunc cannot be nil")
+		err = &invalidFuncError{msg: "srcFunc cannot be nil"}
 		return
 	}
 	if destFunc == nil {
-		err = fmt.Errorf("destFunc cannot be nil")
+		err = &invalidFuncError{msg: "destFunc cannot be nil"}
 		return
 	}
 
 	srcFuncType, destFuncType := reflect.TypeOf(srcFunc), reflect.TypeOf(destFunc)
 	if srcFuncType.Kind() != reflect.Func {
-		err = fmt.Errorf("srcFunc needs to be a function, got %T", srcFunc)
+		err = &invalidFuncError{msg: fmt.Sprintf("srcFunc needs to be a function, got %T", srcFunc)}
 		return
 	}
 	if destFuncType.Kind() != reflect.Ptr {
-		err = fmt.Errorf("destFunc needs to be a pointer of function variable, got %T", srcFunc)
+		err = &invalidFuncError{msg: fmt.Sprintf("destFunc needs to be a pointer of function variable, got %T", srcFunc)}
 		return
 	}
 
 	srcFuncVal := reflect.ValueOf(srcFunc)
 	destFuncVal := reflect.ValueOf(destFunc).Elem()
 	if destFuncVal.Kind() != reflect.Func {
-		err = fmt.Errorf("destFunc needs to be a pointer of function variable, got %T", srcFunc)
+		err = &invalidFuncError{msg: fmt.Sprintf("destFunc needs to be a pointer of function variable, got %T", srcFunc)}
 		return
 	}
 	destFuncValType := destFuncVal.Type()
 
-	if want, have := srcFuncType.NumIn(), destFuncValType.NumIn(); want != have {
-		err = fmt.Errorf("argument mismatch, srcFunc(%d) != destFunc(%d)", want, have)
-		return
+	// fill the bound arguments of srcFunc
+	cfg := newConfig(opts)
+	if cfg.bound() {
+		if srcFuncVal, err = cfg.bind(srcFuncVal, destFuncValType); err != nil {
+			return
+		}
+		srcFuncType = srcFuncVal.Type()
 	}
-	if want, have := srcFuncType.NumOut(), destFuncValType.NumOut(); want != have {
-		err = fmt.Errorf("return mismatch, srcFunc(%d) != destFunc(%d)", want, have)
+
+	if err = checkSignature(srcFuncType, destFuncValType); err != nil {
 		return
 	}
 
-	//
-	// variadic check
-	//
-	if srcFuncType.IsVariadic() && !destFuncValType.IsVariadic() {
-		err = fmt.Errorf("srcFunc is variadic function while destFunc is not")
+	plan, err := cfg.mapper().planWrap(srcFuncType, destFuncValType)
+	if err != nil {
 		return
 	}
-	if !srcFuncType.IsVariadic() && destFuncValType.IsVariadic() {
-		err = fmt.Errorf("destFunc is variadic function while srcFunc is not")
-		return
+	var validators []func(v reflect.Value) error
+	if cfg.validate {
+		if validators, err = argValidators(srcFuncType); err != nil {
+			return
+		}
+	}
+
+	// set the wrapper to the pointer of destFunc
+	destFuncVal.Set(plan.wrap(srcFuncVal, destFuncValType, cfg, validators))
+	return
+}
+
+// wrapPlan is the conversions for wrapping a function type as another
+type wrapPlan struct {
+	funcIn   func([]reflect.Value) error
+	funcOut  func([]reflect.Value) error
+	outTypes []reflect.Type
+	errPos   int
+}
+
+// planWrap maps the conversions for wrapping srcFuncType as destFuncType.
+// The plans are cached by typePair of function types.
+func (m *mapper) planWrap(srcFuncType, destFuncType reflect.Type) (plan *wrapPlan, err error) {
+	if cached, ok := m.plans.Load(typePair{from: srcFuncType, to: destFuncType}); ok {
+		return cached.(*wrapPlan), nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.buildPlan(srcFuncType, destFuncType)
+}
+
+// buildPlan maps the plan with m.mu held
+func (m *mapper) buildPlan(srcFuncType, destFuncType reflect.Type) (plan *wrapPlan, err error) {
+	key := typePair{from: srcFuncType, to: destFuncType}
+	if cached, ok := m.plans.Load(key); ok {
+		return cached.(*wrapPlan), nil
 	}
 
-	//
-	// compose functions for arguments and return variables conversions
-	//
 	numIn, numOut := srcFuncType.NumIn(), srcFuncType.NumOut()
 
+	// collect every mismatch, so a wrong signature is fixed in one pass
+	var errs TypeMismatchErrors
+
 	// generate function input converters
-	outerInTypes := make([]reflect.Type, numIn)
-	innerInTypes := make([]reflect.Type, numIn)
+	inConverters := make([]Converter, numIn)
+	inDirect := make([]bool, numIn)
 	for i := 0; i < numIn; i++ {
-		innerInTypes[i], outerInTypes[i] = srcFuncType.In(i), destFuncValType.In(i)
-	}
-	inConverters, err := MapConverter(outerInTypes, innerInTypes)
-	if err != nil {
-		innerErr := err.(*mapConverterError)
-		err = fmt.Errorf(
-			"argument %d, %s cannot be converted %s",
-			innerErr.pos+1, innerErr.from.String(), innerErr.to.String())
-		return
+		mapped := m.build(destFuncType.In(i), srcFuncType.In(i))
+		if !mapped.ok {
+			errs = append(errs, &TypeMismatchError{
+				kind:   mismatchArgument,
+				pos:    i,
+				from:   destFuncType.In(i),
+				to:     srcFuncType.In(i),
+				reason: mapped.reason,
+			})
+			continue
+		}
+		inConverters[i], inDirect[i] = mapped.conv, mapped.direct
 	}
-	funcIn := makePipe(inConverters)
 
 	// generate function output converters
-	outerOutTypes := make([]reflect.Type, numOut)
-	innerOutTypes := make([]reflect.Type, numOut)
+	outTypes := make([]reflect.Type, numOut)
+	outConverters := make([]Converter, numOut)
+	outDirect := make([]bool, numOut)
 	for i := 0; i < numOut; i++ {
-		innerOutTypes[i], outerOutTypes[i] = srcFuncType.Out(i), destFuncValType.Out(i)
+		outTypes[i] = destFuncType.Out(i)
+		mapped := m.build(srcFuncType.Out(i), outTypes[i])
+		if !mapped.ok {
+			errs = append(errs, &TypeMismatchError{
+				kind:   mismatchRetVar,
+				pos:    i,
+				from:   srcFuncType.Out(i),
+				to:     outTypes[i],
+				reason: mapped.reason,
+			})
+			continue
+		}
+		outConverters[i], outDirect[i] = mapped.conv, mapped.direct
 	}
-	outConverters, err := MapConverter(innerOutTypes, outerOutTypes)
-	if err != nil {
-		innerErr := err.(*mapConverterError)
-		err = fmt.Errorf(
-			"return variable %d, %s cannot be converted %s",
-			innerErr.pos+1, innerErr.from.String(), innerErr.to.String())
+	if len(errs) > 0 {
+		err = errs
 		return
 	}
-	funcOut := makePipe(outConverters)
+
+	plan = &wrapPlan{
+		funcIn:   makePipe(inConverters, inDirect),
+		funcOut:  makePipe(outConverters, outDirect),
+		outTypes: outTypes,
+		errPos:   findLastError(outTypes),
+	}
+
+	// plans of function values being mapped may refer to placeholders
+	if len(m.building) == 0 {
+		m.plans.Store(key, plan)
+	}
+	return
+}
+
+// wrap returns the function of destFuncType calling srcFuncVal with the
+// conversions of the plan. The arguments are validated by the validators,
+// if any, and the errors are handled as configured by cfg.
+func (plan *wrapPlan) wrap(srcFuncVal reflect.Value, destFuncType reflect.Type, cfg *config, validators []func(v reflect.Value) error) reflect.Value {
+	numOut := len(plan.outTypes)
 
 	// default error handling
-	handleError := func(err error, out []reflect.Value) []reflect.Value {
+	handleError := func(err error, out []reflect.Value, from int) []reflect.Value {
 		panic(err)
 	}
 
-	// find the "error" return variable in out
-	// if any, handle the error with the last error parameter
-	if pos := findLastError(outerOutTypes); pos >= 0 {
-		handleError = func(err error, out []reflect.Value) []reflect.Value {
-			// initialize output variables properly, if not already
-			for i := range outerOutTypes {
-				if i != pos && !out[i].IsValid() {
-					out[i] = reflect.Zero(outerOutTypes[i])
-				}
+	switch pos := plan.errPos; {
+	case pos >= 0:
+		// if the wrapper has an "error" return variable, return the error with it
+		handleError = func(err error, out []reflect.Value, from int) []reflect.Value {
+			// reset output variables not yet converted
+			for i := from; i < numOut; i++ {
+				out[i] = reflect.Zero(plan.outTypes[i])
 			}
 
 			// set the conversion error to the output variable
-			out[pos] = reflect.ValueOf(err).Convert(outerOutTypes[pos])
+			out[pos] = reflect.ValueOf(err).Convert(plan.outTypes[pos])
+			return out
+		}
+	case cfg.onError != nil:
+		// otherwise, pass the error to the callback and return zero values
+		handleError = func(err error, out []reflect.Value, from int) []reflect.Value {
+			for i := from; i < numOut; i++ {
+				out[i] = reflect.Zero(plan.outTypes[i])
+			}
+			cfg.onError(err)
 			return out
 		}
 	}
 
-	// compose the wrapped function
-	resultFunc := reflect.MakeFunc(destFuncValType, func(in []reflect.Value) (out []reflect.Value) {
+	// the variadic arguments are already in a slice
+	call := srcFuncVal.Call
+	if srcFuncVal.Type().IsVariadic() {
+		call = srcFuncVal.CallSlice
+	}
+
+	// invoke converts the arguments, calls srcFunc and converts the return
+	// variables. On error, it returns the position of the first return
+	// variable not yet converted.
+	invoke := func(in []reflect.Value) (out []reflect.Value, from int, err error) {
+		if cfg.recoverPanics {
+			defer func() {
+				if r := recover(); r != nil {
+					out, from, err = make([]reflect.Value, numOut), 0, newPanicError(r)
+				}
+			}()
+		}
+
 		// convert input arguments
-		innerIn, err := funcIn(in)
-		if err != nil {
-			// initialize the output array
-			innerErr := err.(*convertError)
-			return handleError(
-				&ArgumentError{
+		if plan.funcIn != nil {
+			if err := plan.funcIn(in); err != nil {
+				innerErr := err.(*convertError)
+				return make([]reflect.Value, numOut), 0, &ArgumentError{
 					pos: innerErr.pos,
 					err: innerErr.err,
-				},
-				make([]reflect.Value, numOut),
-			)
+				}
+			}
+		}
+
+		// validate the converted arguments
+		for i, validate := range validators {
+			if validate == nil {
+				continue
+			}
+			if err := validate(in[i]); err != nil {
+				return make([]reflect.Value, numOut), 0, &ArgumentError{pos: i, err: err}
+			}
 		}
 
 		// call srcFunc function
-		innerOut := srcFuncVal.Call(innerIn)
+		out = call(in)
 
 		// convert return variables
-		out, err = funcOut(innerOut)
-		if err != nil {
-			innerErr := err.(*convertError)
-			return handleError(
-				&RetVarError{
+		if plan.funcOut != nil {
+			if err := plan.funcOut(out); err != nil {
+				innerErr := err.(*convertError)
+				return out, innerErr.pos, &RetVarError{
 					pos: innerErr.pos,
 					err: innerErr.err,
-				},
-				out,
-			)
+				}
+			}
 		}
 
 		return
+	}
+
+	// compose the wrapped function
+	return reflect.MakeFunc(destFuncType, func(in []reflect.Value) []reflect.Value {
+		out, from, err := invoke(in)
+		if err != nil {
+			return handleError(err, out, from)
+		}
+		return out
 	})
-	// set the resultFunc to the pointer of destFunc
-	destFuncVal.Set(resultFunc)
+}
+
+// mapFunc maps the converter wrapping functions of type from as type to,
+// as WrapAs does without options. Nil functions are converted into nil.
+func (m *mapper) mapFunc(from, to reflect.Type) (mapped mappedConverter) {
+	if mapped.reason = checkSignature(from, to); mapped.reason != nil {
+		return
+	}
+	plan, err := m.buildPlan(from, to)
+	if err != nil {
+		mapped.reason = err
+		return
+	}
+
+	cfg := &config{}
+	mapped.conv = func(src reflect.Value) (reflect.Value, error) {
+		if src.IsNil() {
+			return reflect.Zero(to), nil
+		}
+		return plan.wrap(src, to, cfg, nil), nil
+	}
+	mapped.ok = true
 	return
 }
@@ -0,0 +1,70 @@
+package funconv_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-midway/midway/funconv"
+)
+
+func benchLength(ctx context.Context, name string) (length int, err error) {
+	length = len(name)
+	return
+}
+
+func BenchmarkWrapAs(b *testing.B) {
+	var endpoint func(ctx context.Context, req interface{}) (resp interface{}, err error)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := funconv.WrapAs(benchLength, &endpoint); err != nil {
+			b.Fatalf("unexpected error: %s", err.Error())
+		}
+	}
+}
+
+func BenchmarkWrapAs_callPassthrough(b *testing.B) {
+	var endpoint func(ctx context.Context, name string) (length int, err error)
+	if err := funconv.WrapAs(benchLength, &endpoint); err != nil {
+		b.Fatalf("unexpected error: %s", err.Error())
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		endpoint(ctx, "hello")
+	}
+}
+
+func BenchmarkWrapAs_callEndpoint(b *testing.B) {
+	var endpoint func(ctx context.Context, req interface{}) (resp interface{}, err error)
+	if err := funconv.WrapAs(benchLength, &endpoint); err != nil {
+		b.Fatalf("unexpected error: %s", err.Error())
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		endpoint(ctx, "hello")
+	}
+}
+
+func BenchmarkMapConverter(b *testing.B) {
+	inTypes := []reflect.Type{
+		reflect.TypeOf((*context.Context)(nil)).Elem(),
+		reflect.TypeOf((*interface{})(nil)).Elem(),
+		reflect.TypeOf(stringer(0)),
+	}
+	outTypes := []reflect.Type{
+		reflect.TypeOf((*context.Context)(nil)).Elem(),
+		reflect.TypeOf(""),
+		reflect.TypeOf((*fmt.Stringer)(nil)).Elem(),
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := funconv.MapConverter(inTypes, outTypes); err != nil {
+			b.Fatalf("unexpected error: %s", err.Error())
+		}
+	}
+}
@@ -16,8 +16,8 @@ func TestConvertError(t *testing.T) {
 	}
 }
 
-func TestMapConvertError(t *testing.T) {
-	var err error = &mapConverterError{
+func TestTypeMismatchError(t *testing.T) {
+	var err error = &TypeMismatchError{
 		pos:  123,
 		from: reflect.TypeOf("hello"),
 		to:   reflect.TypeOf(0),
@@ -72,3 +72,36 @@ func TestFindLastError(t *testing.T) {
 		}
 	}
 }
+
+func TestPlanWrap_cache(t *testing.T) {
+	var src func(string) (interface{}, error)
+	var dest func(interface{}) (int, error)
+	srcType, destType := reflect.TypeOf(src), reflect.TypeOf(dest)
+
+	plan1, err := DefaultRegistry.mapper().planWrap(srcType, destType)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	plan2, err := DefaultRegistry.mapper().planWrap(srcType, destType)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if plan1 != plan2 {
+		t.Errorf("expected the cached plan %p, got %p", plan1, plan2)
+	}
+	if want, have := 1, plan1.errPos; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if plan1.funcIn == nil || plan1.funcOut == nil {
+		t.Errorf("expected conversions, got direct maps")
+	}
+
+	// direct maps need no conversion
+	plan3, err := DefaultRegistry.mapper().planWrap(reflect.TypeOf(dest), reflect.TypeOf(dest))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if plan3.funcIn != nil || plan3.funcOut != nil {
+		t.Errorf("expected direct maps, got conversions")
+	}
+}
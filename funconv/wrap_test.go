@@ -2,8 +2,10 @@ package funconv_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -236,6 +238,91 @@ func TestWrap_variadicCheck(t *testing.T) {
 
 }
 
+func TestWrap_variadicElements(t *testing.T) {
+	join := func(sep string, names ...string) (string, error) {
+		return strings.Join(names, sep), nil
+	}
+	var dest func(sep string, names ...interface{}) (string, error)
+	err := funconv.WrapAs(join, &dest)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	resp, err := dest(",", "a", "b", "c")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := "a,b,c", resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	resp, err = dest(",")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := "", resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	_, err = dest(",", "a", 2)
+	if _, ok := err.(*funconv.ArgumentError); !ok {
+		t.Errorf("expected *funconv.ArgumentError, got %#v", err)
+	}
+	if want, have := "argument 2, element 1, int cannot be converted to string", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_variadicToSlice(t *testing.T) {
+	sum := func(nums ...int) (int, error) {
+		total := 0
+		for _, num := range nums {
+			total += num
+		}
+		return total, nil
+	}
+	var dest func(nums []interface{}) (interface{}, error)
+	err := funconv.WrapAs(sum, &dest)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	resp, err := dest([]interface{}{1, 2, 3})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := 6, resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	resp, err = dest(nil)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := 0, resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_sliceToVariadic(t *testing.T) {
+	count := func(ctx context.Context, names []string) (int, error) {
+		return len(names), nil
+	}
+	var dest func(ctx context.Context, names ...interface{}) (int, error)
+	err := funconv.WrapAs(count, &dest)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	resp, err := dest(context.Background(), "a", "b")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := 2, resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
 func TestWrap_passthrough(t *testing.T) {
 	var err error
 	// srcFunc and destFunc
@@ -386,6 +473,30 @@ func TestWrap_endpointInputCastingError(t *testing.T) {
 	} else if want, have := "argument 2, int cannot be converted to string", err.Error(); want != have {
 		t.Errorf("expected %#v, got %#v", want, have)
 	}
+
+	// test3: nil cannot be converted to string
+	_, err = endpoint2(nil, nil)
+	if err == nil {
+		t.Errorf("expected error, got nil")
+	} else if want, have := "argument 2, <nil> cannot be converted to string", err.Error(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if _, ok := err.(*funconv.ArgumentError); !ok {
+		t.Errorf("expected *funconv.ArgumentError, got %T", err)
+	}
+
+	// test4: nil is converted to nil slice
+	countFunc := func(ctx context.Context, names []string) (count int, err error) {
+		count = len(names)
+		return
+	}
+	if err = funconv.WrapAs(countFunc, &endpoint2); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if resp, err := endpoint2(nil, nil); err != nil || resp != 0 {
+		t.Errorf("expected 0, got %#v, %v", resp, err)
+	}
 }
 
 func TestWrap_endpointOutputCastingError(t *testing.T) {
@@ -511,3 +622,246 @@ func TestWrap_endpoint(t *testing.T) {
 		t.Errorf("expected %#v, got %#v", want, have)
 	}
 }
+
+func TestWrap_retVarErrorZeroValues(t *testing.T) {
+	faultyFunc := func() (interface{}, interface{}, string, error) {
+		return 1, "not an int", "hello", nil
+	}
+	var endpoint func() (int, int, string, error)
+	if err := funconv.WrapAs(faultyFunc, &endpoint); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	// return variables from the failed one are reset to zero values
+	r1, r2, r3, err := endpoint()
+	if want, have := "return variable 2, string cannot be converted to int", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 1, r1; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 0, r2; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "", r3; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestWrap_recoverPanics(t *testing.T) {
+	divide := func(a, b int) (int, error) {
+		return a / b, nil
+	}
+	var dest func(a, b interface{}) (interface{}, error)
+	err := funconv.WrapAs(divide, &dest, funconv.RecoverPanics())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	resp, err := dest(1, 0)
+	if want, have := interface{}(nil), resp; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	var panicErr *funconv.PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected *funconv.PanicError, got %#v", err)
+	}
+	if want, have := "panic: runtime error: integer divide by zero", err.Error(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	var runtimeErr runtime.Error
+	if !errors.As(err, &runtimeErr) {
+		t.Errorf("expected runtime.Error, got %#v", panicErr.Value())
+	}
+	if want, have := "TestWrap_recoverPanics", string(panicErr.Stack()); !strings.Contains(have, want) {
+		t.Errorf("expected stack to contain %#v, got %#v", want, have)
+	}
+
+	// conversion errors are returned as usual
+	_, err = dest("1", 2)
+	if _, ok := err.(*funconv.ArgumentError); !ok {
+		t.Errorf("expected *funconv.ArgumentError, got %#v", err)
+	}
+}
+
+func TestWrap_recoverPanicsNoError(t *testing.T) {
+	crash := func(name string) int {
+		panic("crashed " + name)
+	}
+	var dest func(name interface{}) int
+	err := funconv.WrapAs(crash, &dest, funconv.RecoverPanics())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	// panicked again as *PanicError without OnError
+	defer func() {
+		r := recover()
+		panicErr, ok := r.(*funconv.PanicError)
+		if !ok {
+			t.Fatalf("expected *funconv.PanicError, got %#v", r)
+		}
+		if want, have := "crashed gopher", panicErr.Value(); want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+	}()
+	dest("gopher")
+}
+
+func TestWrap_onError(t *testing.T) {
+	var errs []error
+	onError := funconv.OnError(func(err error) {
+		errs = append(errs, err)
+	})
+
+	crash := func(name string) int {
+		if name == "" {
+			panic("empty name")
+		}
+		return len(name)
+	}
+	var dest func(name interface{}) int
+	err := funconv.WrapAs(crash, &dest, funconv.RecoverPanics(), onError)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if want, have := 5, dest("hello"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 0, dest(""); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 0, dest(123); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	if want, have := 2, len(errs); want != have {
+		t.Fatalf("expected %#v, got %#v", want, have)
+	}
+	if _, ok := errs[0].(*funconv.PanicError); !ok {
+		t.Errorf("expected *funconv.PanicError, got %#v", errs[0])
+	}
+	if _, ok := errs[1].(*funconv.ArgumentError); !ok {
+		t.Errorf("expected *funconv.ArgumentError, got %#v", errs[1])
+	}
+
+	// without RecoverPanics, panics of srcFunc propagate
+	err = funconv.WrapAs(crash, &dest, onError)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	defer func() {
+		if want, have := "empty name", recover(); want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+	}()
+	dest("")
+}
+
+func TestWrap_funcArgs(t *testing.T) {
+	// each takes a callback of the exact type
+	each := func(names []string, fn func(name string) error) error {
+		for _, name := range names {
+			if err := fn(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// the callback given is of another type
+	var eachItfce func(names []string, fn func(v interface{}) error) error
+	if err := funconv.WrapAs(each, &eachItfce); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	var visited []string
+	err := eachItfce([]string{"a", "b"}, func(v interface{}) error {
+		visited = append(visited, v.(string))
+		return nil
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+	if want, have := "a,b", strings.Join(visited, ","); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// nil callbacks are converted into nil
+	var checkNil func(fn func(v interface{}) error) bool
+	if err := funconv.WrapAs(func(fn func(string) error) bool { return fn == nil }, &checkNil); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !checkNil(nil) {
+		t.Errorf("expected nil callback")
+	}
+}
+
+func TestWrap_funcArgsErrors(t *testing.T) {
+	// eachItfce takes a callback of interface{}
+	eachItfce := func(values []interface{}, fn func(v interface{}) error) error {
+		for _, v := range values {
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// the callback given takes string, so the conversion may fail
+	// and is returned with the error of the callback
+	var each func(values []interface{}, fn func(name string) error) error
+	if err := funconv.WrapAs(eachItfce, &each); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	var visited []string
+	err := each([]interface{}{"a", 1, "b"}, func(name string) error {
+		visited = append(visited, name)
+		return nil
+	})
+	if want, have := "argument 1, int cannot be converted to string", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	var argErr *funconv.ArgumentError
+	if !errors.As(err, &argErr) {
+		t.Errorf("expected *funconv.ArgumentError, got %#v", err)
+	}
+	if want, have := "a", strings.Join(visited, ","); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// mismatched callbacks are errors of WrapAs
+	var mismatched func(values []interface{}, fn func(n int, name string) error) error
+	err = funconv.WrapAs(eachItfce, &mismatched)
+	if want, have := "argument 2, func(int, string) error cannot be converted func(interface {}) error: argument mismatch, srcFunc(2) != destFunc(1)", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if !errors.Is(err, funconv.ErrArityMismatch) {
+		t.Errorf("expected ErrArityMismatch, got %#v", err)
+	}
+}
+
+func TestMapConverter_funcs(t *testing.T) {
+	type lengthFunc func(s string) int
+
+	converters, err := funconv.MapConverter(
+		[]reflect.Type{reflect.TypeOf(func(s interface{}) int { return len(s.(string)) })},
+		[]reflect.Type{reflect.TypeOf(lengthFunc(nil))},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	fn := converters[0].Convert(func(s interface{}) int { return len(s.(string)) }).(lengthFunc)
+	if want, have := 5, fn("hello"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// return variables are converted as well
+	_, err = funconv.MapConverter(
+		[]reflect.Type{reflect.TypeOf(func() string { return "" })},
+		[]reflect.Type{reflect.TypeOf(func() int { return 0 })},
+	)
+	if want, have := "at 0, func() string cannot be converted to func() int: return variable 1, string cannot be converted int", fmt.Sprintf("%v", err); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
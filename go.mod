@@ -0,0 +1,16 @@
+module github.com/go-midway/midway
+
+go 1.22
+
+require (
+	github.com/go-kit/kit v0.10.0
+	github.com/go-sql-driver/mysql v1.5.0
+	github.com/jinzhu/gorm v1.9.16
+	github.com/lib/pq v1.1.1
+	github.com/mattn/go-sqlite3 v1.14.0
+)
+
+require (
+	github.com/go-logfmt/logfmt v0.5.0 // indirect
+	github.com/jinzhu/inflection v1.0.0 // indirect
+)
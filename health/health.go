@@ -0,0 +1,190 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Checker checks the health of a dependency
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc turns a function into a Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements Checker
+func (fn CheckerFunc) Check(ctx context.Context) error {
+	return fn(ctx)
+}
+
+// Source provides the named checkers to run for a request
+type Source func(r *http.Request) map[string]Checker
+
+// Static returns a Source that always provides checkers
+func Static(checkers map[string]Checker) Source {
+	return func(r *http.Request) map[string]Checker {
+		return checkers
+	}
+}
+
+// Options configures Handler
+type Options struct {
+	// Timeout of each check. Defaults to 1 second.
+	Timeout time.Duration
+
+	// CacheTTL is how long a result is reused before the dependency is
+	// checked again. Zero means no caching.
+	CacheTTL time.Duration
+}
+
+// Result is the status of a single dependency
+type Result struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Latency   float64   `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the response body of Handler
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type handler struct {
+	opts    Options
+	sources []Source
+
+	mu    sync.Mutex
+	cache map[string]Result
+}
+
+// Handler returns a http.Handler that runs the checkers of every source
+// concurrently, and responds a JSON Report. The status code is 200 OK if
+// every check passes, or 503 Service Unavailable otherwise.
+//
+// It is meant to be mounted as /healthz or /readyz. Sources that read
+// from request context (e.g. gormcontext.DBCheckers) need the handler to
+// be chained after the middlewares providing the context.
+func Handler(opts Options, sources ...Source) http.Handler {
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	return &handler{
+		opts:    opts,
+		sources: sources,
+		cache:   make(map[string]Result),
+	}
+}
+
+func (h *handler) cached(name string, now time.Time) (result Result, ok bool) {
+	if h.opts.CacheTTL <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if result, ok = h.cache[name]; ok && now.Sub(result.CheckedAt) >= h.opts.CacheTTL {
+		ok = false
+	}
+	return
+}
+
+func (h *handler) store(name string, result Result) {
+	if h.opts.CacheTTL <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cache[name] = result
+}
+
+func (h *handler) check(ctx context.Context, checker Checker) (result Result) {
+	ctx, cancel := context.WithTimeout(ctx, h.opts.Timeout)
+	defer cancel()
+
+	result.CheckedAt = time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result.Latency = float64(time.Since(result.CheckedAt)) / float64(time.Millisecond)
+
+	result.Status = "ok"
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+	}
+	return
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	checkers := make(map[string]Checker)
+	for _, source := range h.sources {
+		for name, checker := range source(r) {
+			checkers[name] = checker
+		}
+	}
+
+	names := make([]string, 0, len(checkers))
+	for name := range checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// the results are cached for other probes, so the checks are not
+	// canceled with the request of this probe, but by the timeout
+	ctx := context.WithoutCancel(r.Context())
+
+	now := time.Now()
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		if result, ok := h.cached(name, now); ok {
+			results[i] = result
+			continue
+		}
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i] = h.check(ctx, checkers[name])
+			h.store(name, results[i])
+		}(i, name)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: "ok",
+		Checks: make(map[string]Result, len(names)),
+	}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != "ok" {
+			report.Status = "error"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	if report.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
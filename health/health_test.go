@@ -0,0 +1,133 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-midway/midway/health"
+)
+
+func serveReport(t *testing.T, handler http.Handler) (code int, report health.Report) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/readyz", nil)
+	handler.ServeHTTP(w, r)
+	if want, have := "application/json; charset=utf-8", w.Header().Get("Content-Type"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	return w.Code, report
+}
+
+func TestHandler(t *testing.T) {
+	handler := health.Handler(health.Options{}, health.Static(map[string]health.Checker{
+		"ok": health.CheckerFunc(func(ctx context.Context) error {
+			return nil
+		}),
+	}))
+
+	code, report := serveReport(t, handler)
+	if want, have := http.StatusOK, code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "ok", report.Status; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "ok", report.Checks["ok"].Status; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestHandler_failure(t *testing.T) {
+	handler := health.Handler(health.Options{
+		Timeout: 10 * time.Millisecond,
+	}, health.Static(map[string]health.Checker{
+		"ok": health.CheckerFunc(func(ctx context.Context) error {
+			return nil
+		}),
+		"error": health.CheckerFunc(func(ctx context.Context) error {
+			return fmt.Errorf("some error")
+		}),
+	}), health.Static(map[string]health.Checker{
+		"slow": health.CheckerFunc(func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}),
+	}))
+
+	code, report := serveReport(t, handler)
+	if want, have := http.StatusServiceUnavailable, code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "error", report.Status; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 3, len(report.Checks); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "ok", report.Checks["ok"].Status; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "some error", report.Checks["error"].Error; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := context.DeadlineExceeded.Error(), report.Checks["slow"].Error; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if latency := report.Checks["slow"].Latency; latency < 10 || latency > 500 {
+		t.Errorf("unexpected latency: %#v", latency)
+	}
+}
+
+func TestHandler_cache(t *testing.T) {
+	calls := 0
+	source := health.Static(map[string]health.Checker{
+		"counted": health.CheckerFunc(func(ctx context.Context) error {
+			calls++
+			return nil
+		}),
+	})
+
+	handler := health.Handler(health.Options{CacheTTL: time.Hour}, source)
+	serveReport(t, handler)
+	serveReport(t, handler)
+	if want, have := 1, calls; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	calls = 0
+	handler = health.Handler(health.Options{}, source)
+	serveReport(t, handler)
+	serveReport(t, handler)
+	if want, have := 2, calls; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestHandler_canceledProbe(t *testing.T) {
+	handler := health.Handler(health.Options{CacheTTL: time.Hour}, health.Static(map[string]health.Checker{
+		"db": health.CheckerFunc(func(ctx context.Context) error {
+			return ctx.Err()
+		}),
+	}))
+
+	// the client of the first probe has gone away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r, _ := http.NewRequestWithContext(ctx, "GET", "/readyz", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	code, report := serveReport(t, handler)
+	if want, have := http.StatusOK, code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "ok", report.Checks["db"].Status; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
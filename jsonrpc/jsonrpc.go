@@ -0,0 +1,98 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/go-midway/midway/funconv"
+)
+
+// Error codes defined by the JSON-RPC 2.0 specification
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Error is the error object of a response. Registered functions may
+// return an *Error to respond with their own code and data.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (err *Error) Error() string {
+	return err.Message
+}
+
+// ParamError is the data of the Invalid params error object
+type ParamError struct {
+	Position int    `json:"position"`
+	Error    string `json:"error"`
+}
+
+// toError converts err, returned by a method call, into the error object.
+// Errors other than *Error and *funconv.ArgumentError are internal,
+// so their messages are not exposed.
+func toError(err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+	var argErr *funconv.ArgumentError
+	if errors.As(err, &argErr) {
+		return &Error{
+			Code:    CodeInvalidParams,
+			Message: "Invalid params",
+			Data: ParamError{
+				Position: argErr.Position(),
+				Error:    argErr.Error(),
+			},
+		}
+	}
+	return &Error{
+		Code:    CodeInternalError,
+		Message: "Internal error",
+	}
+}
+
+// request is a request object, or a notification if ID is absent
+type request struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+func (req *request) isNotification() bool {
+	return req.ID == nil
+}
+
+// response is a response object
+type response struct {
+	Version string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+var null = json.RawMessage("null")
+
+func newResponse(id json.RawMessage, result interface{}, err *Error) *response {
+	if id == nil {
+		id = null
+	}
+	if err == nil && result == nil {
+		// result is required on success
+		result = null
+	}
+	return &response{
+		Version: "2.0",
+		Result:  result,
+		Error:   err,
+		ID:      id,
+	}
+}
@@ -0,0 +1,339 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/go-midway/midway/funconv"
+	"github.com/go-midway/midway/logcontext"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+
+	// jsonTypes are the types of scalar values decoded from JSON
+	// into interface{}
+	jsonTypes = []reflect.Type{
+		reflect.TypeOf(float64(0)),
+		reflect.TypeOf(""),
+		reflect.TypeOf(false),
+	}
+)
+
+// param is an argument of a registered function
+type param struct {
+	typ   reflect.Type
+	convs map[reflect.Type]funconv.Converter
+}
+
+// decode decodes raw JSON into the type of the argument
+func (p *param) decode(raw json.RawMessage) (val reflect.Value, err error) {
+	var v interface{}
+	if err = json.Unmarshal(raw, &v); err != nil {
+		return
+	}
+	if v == nil {
+		return reflect.Zero(p.typ), nil
+	}
+	if conv, ok := p.convs[reflect.TypeOf(v)]; ok {
+		return conv(reflect.ValueOf(v))
+	}
+
+	// arrays, objects and scalars not converted into the type
+	// are decoded as JSON
+	ptr := reflect.New(p.typ)
+	if err = json.Unmarshal(raw, ptr.Interface()); err != nil {
+		return
+	}
+	return ptr.Elem(), nil
+}
+
+// method is a registered function
+type method struct {
+	fn      reflect.Value
+	withCtx bool
+	params  []param
+	names   map[string]int
+	result  bool
+	errPos  int
+}
+
+// Server serves the registered functions as JSON-RPC 2.0 methods
+// over HTTP POST
+type Server struct {
+	mu      sync.RWMutex
+	methods map[string]*method
+	opts    []funconv.Option
+}
+
+// NewServer returns a *Server without methods. The params are converted
+// into the argument types with the opts, the CheckedNumbers, ParseStrings
+// and TextMarshaling rules of funconv.
+func NewServer(opts ...funconv.Option) *Server {
+	return &Server{
+		methods: make(map[string]*method),
+		opts: append(opts[:len(opts):len(opts)],
+			funconv.WithRules(funconv.CheckedNumbers, funconv.ParseStrings, funconv.TextMarshaling)),
+	}
+}
+
+// Register registers fn as the method of the name. The function may take
+// a context.Context as the first argument, which is the context of the
+// HTTP request. It may return a result, an error, or both in this order.
+//
+// The params of a call are given by position, or by the paramNames if
+// given as an object. If paramNames are not given and fn takes a single
+// argument, the params object is the argument. Params not given are the
+// zero values of the argument types.
+func (s *Server) Register(name string, fn interface{}, paramNames ...string) error {
+	fnType := reflect.TypeOf(fn)
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		return fmt.Errorf("jsonrpc: %s needs to be a function, got %T", name, fn)
+	}
+	if fnType.IsVariadic() {
+		return fmt.Errorf("jsonrpc: %s cannot be a variadic function", name)
+	}
+
+	m := &method{
+		fn:     reflect.ValueOf(fn),
+		errPos: -1,
+	}
+
+	// arguments
+	first := 0
+	if fnType.NumIn() > 0 && fnType.In(0) == contextType {
+		m.withCtx, first = true, 1
+	}
+	for i := first; i < fnType.NumIn(); i++ {
+		typ := fnType.In(i)
+		convs := make(map[reflect.Type]funconv.Converter)
+		inTypes := make([]reflect.Type, len(jsonTypes))
+		for j := range jsonTypes {
+			inTypes[j] = typ
+		}
+
+		// converters of mismatched types are nil
+		converters, _ := funconv.MapConverter(jsonTypes, inTypes, s.opts...)
+		for j, conv := range converters {
+			if conv != nil {
+				convs[jsonTypes[j]] = conv
+			}
+		}
+		m.params = append(m.params, param{typ: typ, convs: convs})
+	}
+	if len(paramNames) > 0 {
+		if want, have := len(m.params), len(paramNames); want != have {
+			return fmt.Errorf("jsonrpc: %s takes %d params, got %d names", name, want, have)
+		}
+		m.names = make(map[string]int, len(paramNames))
+		for i, paramName := range paramNames {
+			m.names[paramName] = i
+		}
+	}
+
+	// return variables
+	switch fnType.NumOut() {
+	case 0:
+	case 1:
+		if fnType.Out(0) == errorType {
+			m.errPos = 0
+		} else {
+			m.result = true
+		}
+	case 2:
+		if fnType.Out(1) != errorType {
+			return fmt.Errorf("jsonrpc: %s needs to return error as the last variable", name)
+		}
+		m.result, m.errPos = true, 1
+	default:
+		return fmt.Errorf("jsonrpc: %s returns too many variables", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods[name] = m
+	return nil
+}
+
+// invalidParams returns the Invalid params error object
+func invalidParams(format string, a ...interface{}) *Error {
+	return &Error{
+		Code:    CodeInvalidParams,
+		Message: "Invalid params",
+		Data:    fmt.Sprintf(format, a...),
+	}
+}
+
+// args decodes the params into the arguments of the method
+func (m *method) args(params json.RawMessage) (args []reflect.Value, err error) {
+	args = make([]reflect.Value, len(m.params))
+	var positional []json.RawMessage
+
+	switch trimmed := bytes.TrimSpace(params); {
+	case len(trimmed) == 0 || bytes.Equal(trimmed, null):
+	case trimmed[0] == '[':
+		if err = json.Unmarshal(trimmed, &positional); err != nil {
+			return nil, invalidParams("%s", err.Error())
+		}
+	case trimmed[0] == '{' && m.names == nil && len(m.params) == 1:
+		positional = []json.RawMessage{trimmed}
+	case trimmed[0] == '{' && m.names != nil:
+		var named map[string]json.RawMessage
+		if err = json.Unmarshal(trimmed, &named); err != nil {
+			return nil, invalidParams("%s", err.Error())
+		}
+		positional = make([]json.RawMessage, len(m.params))
+		for name, raw := range named {
+			i, ok := m.names[name]
+			if !ok {
+				return nil, invalidParams("unknown param %s", name)
+			}
+			positional[i] = raw
+		}
+	case trimmed[0] == '{':
+		return nil, invalidParams("params need to be an array")
+	default:
+		return nil, invalidParams("params need to be an array or an object")
+	}
+
+	if len(positional) > len(m.params) {
+		return nil, invalidParams("expected %d params, got %d", len(m.params), len(positional))
+	}
+	for i, p := range m.params {
+		if i >= len(positional) || positional[i] == nil {
+			args[i] = reflect.Zero(p.typ)
+			continue
+		}
+		if args[i], err = p.decode(positional[i]); err != nil {
+			return nil, funconv.NewArgumentError(i, err)
+		}
+	}
+	return
+}
+
+// call calls the method with the params
+func (m *method) call(ctx context.Context, params json.RawMessage) (result interface{}, err error) {
+	args, err := m.args(params)
+	if err != nil {
+		return
+	}
+	if m.withCtx {
+		args = append([]reflect.Value{reflect.ValueOf(ctx)}, args...)
+	}
+
+	out := m.fn.Call(args)
+	if m.errPos >= 0 {
+		if errVal := out[m.errPos]; !errVal.IsNil() {
+			return nil, errVal.Interface().(error)
+		}
+	}
+	if m.result {
+		result = out[0].Interface()
+	}
+	return
+}
+
+// handle handles a request object. The response of a notification is nil.
+func (s *Server) handle(ctx context.Context, raw json.RawMessage) *response {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil || req.Version != "2.0" || req.Method == "" {
+		return newResponse(req.ID, nil, &Error{
+			Code:    CodeInvalidRequest,
+			Message: "Invalid Request",
+		})
+	}
+
+	s.mu.RLock()
+	m, ok := s.methods[req.Method]
+	s.mu.RUnlock()
+
+	var result interface{}
+	var rpcErr *Error
+	if !ok {
+		rpcErr = &Error{
+			Code:    CodeMethodNotFound,
+			Message: "Method not found",
+		}
+	} else if res, err := m.call(ctx, req.Params); err != nil {
+		if rpcErr = toError(err); rpcErr.Code == CodeInternalError {
+			logcontext.GetComplexLogger(ctx).Error(
+				"at", "error",
+				"method", req.Method,
+				"err", err.Error(),
+			)
+		}
+	} else {
+		result = res
+	}
+
+	if req.isNotification() {
+		return nil
+	}
+	return newResponse(req.ID, result, rpcErr)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(v)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err == nil && !json.Valid(body) {
+		err = fmt.Errorf("invalid JSON")
+	}
+	if err != nil {
+		writeJSON(w, newResponse(nil, nil, &Error{
+			Code:    CodeParseError,
+			Message: "Parse error",
+		}))
+		return
+	}
+
+	ctx := r.Context()
+	body = bytes.TrimSpace(body)
+	if body[0] != '[' {
+		if resp := s.handle(ctx, body); resp != nil {
+			writeJSON(w, resp)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// batch
+	var batch []json.RawMessage
+	json.Unmarshal(body, &batch)
+	if len(batch) == 0 {
+		writeJSON(w, newResponse(nil, nil, &Error{
+			Code:    CodeInvalidRequest,
+			Message: "Invalid Request",
+		}))
+		return
+	}
+	responses := make([]*response, 0, len(batch))
+	for _, raw := range batch {
+		if resp := s.handle(ctx, raw); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, responses)
+}
@@ -0,0 +1,284 @@
+package jsonrpc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-midway/midway"
+	"github.com/go-midway/midway/jsonrpc"
+	"github.com/go-midway/midway/logcontext"
+)
+
+type greeting struct {
+	Name  string `json:"name"`
+	Times int    `json:"times"`
+}
+
+// newServer returns a server of test methods, chained with loggers
+// writing errors to errs
+func newServer(t *testing.T, errs *bytes.Buffer) (http.Handler, *[]string) {
+	var notified []string
+	server := jsonrpc.NewServer()
+	register := func(name string, fn interface{}, paramNames ...string) {
+		if err := server.Register(name, fn, paramNames...); err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+	}
+	register("subtract", func(minuend, subtrahend int) int {
+		return minuend - subtrahend
+	}, "minuend", "subtrahend")
+	register("sleep", func(ctx context.Context, d time.Duration) (string, error) {
+		return d.String(), ctx.Err()
+	})
+	register("greet", func(g greeting) string {
+		return strings.Repeat("hello "+g.Name+" ", g.Times)
+	})
+	register("sum", func(nums []int) (total int) {
+		for _, n := range nums {
+			total += n
+		}
+		return
+	})
+	register("total", func(counts map[string]int) (total int) {
+		for _, n := range counts {
+			total += n
+		}
+		return
+	})
+	register("notify", func(msg string) {
+		notified = append(notified, msg)
+	})
+	register("fail", func() error {
+		return errors.New("database is down")
+	})
+	register("teapot", func() (interface{}, error) {
+		return nil, &jsonrpc.Error{Code: 418, Message: "I'm a teapot", Data: "short and stout"}
+	})
+
+	handler := midway.Chain(
+		logcontext.ProvideLoggers(kitlog.NewNopLogger(), kitlog.NewLogfmtLogger(errs)),
+	)(server)
+	return handler, &notified
+}
+
+// post posts body to the handler and returns the status and response body
+func post(handler http.Handler, body string) (int, string) {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/rpc", strings.NewReader(body)))
+	return w.Code, w.Body.String()
+}
+
+func TestServer(t *testing.T) {
+	errs := &bytes.Buffer{}
+	handler, _ := newServer(t, errs)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "positional params",
+			body: `{"jsonrpc": "2.0", "method": "subtract", "params": [42, 23], "id": 1}`,
+			want: `{"jsonrpc":"2.0","result":19,"id":1}`,
+		},
+		{
+			name: "named params",
+			body: `{"jsonrpc": "2.0", "method": "subtract", "params": {"subtrahend": 23, "minuend": 42}, "id": "a"}`,
+			want: `{"jsonrpc":"2.0","result":19,"id":"a"}`,
+		},
+		{
+			name: "converted params",
+			body: `{"jsonrpc": "2.0", "method": "sleep", "params": ["1m30s"], "id": 2}`,
+			want: `{"jsonrpc":"2.0","result":"1m30s","id":2}`,
+		},
+		{
+			name: "struct params",
+			body: `{"jsonrpc": "2.0", "method": "greet", "params": {"name": "gopher", "times": 2}, "id": 3}`,
+			want: `{"jsonrpc":"2.0","result":"hello gopher hello gopher ","id":3}`,
+		},
+		{
+			name: "slice params",
+			body: `{"jsonrpc": "2.0", "method": "sum", "params": [[1, 2, 3]], "id": 11}`,
+			want: `{"jsonrpc":"2.0","result":6,"id":11}`,
+		},
+		{
+			name: "map params",
+			body: `{"jsonrpc": "2.0", "method": "total", "params": [{"a": 1, "b": 2}], "id": 12}`,
+			want: `{"jsonrpc":"2.0","result":3,"id":12}`,
+		},
+		{
+			name: "invalid slice params",
+			body: `{"jsonrpc": "2.0", "method": "sum", "params": [[1, "2"]], "id": 13}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":{"position":0,"error":"argument 1, json: cannot unmarshal string into .1 of type int"}},"id":13}`,
+		},
+		{
+			name: "no result",
+			body: `{"jsonrpc": "2.0", "method": "notify", "params": ["hi"], "id": null}`,
+			want: `{"jsonrpc":"2.0","result":null,"id":null}`,
+		},
+		{
+			name: "invalid params",
+			body: `{"jsonrpc": "2.0", "method": "subtract", "params": [42, 2.5], "id": 4}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":{"position":1,"error":"argument 2, 2.5 cannot be converted to int without truncation"}},"id":4}`,
+		},
+		{
+			name: "too many params",
+			body: `{"jsonrpc": "2.0", "method": "subtract", "params": [1, 2, 3], "id": 5}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"expected 2 params, got 3"},"id":5}`,
+		},
+		{
+			name: "unknown named param",
+			body: `{"jsonrpc": "2.0", "method": "subtract", "params": {"foo": 1}, "id": 6}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"unknown param foo"},"id":6}`,
+		},
+		{
+			name: "method not found",
+			body: `{"jsonrpc": "2.0", "method": "foobar", "id": 7}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":7}`,
+		},
+		{
+			name: "internal error",
+			body: `{"jsonrpc": "2.0", "method": "fail", "id": 8}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":8}`,
+		},
+		{
+			name: "error object",
+			body: `{"jsonrpc": "2.0", "method": "teapot", "id": 9}`,
+			want: `{"jsonrpc":"2.0","error":{"code":418,"message":"I'm a teapot","data":"short and stout"},"id":9}`,
+		},
+		{
+			name: "invalid request",
+			body: `{"jsonrpc": "1.0", "method": "subtract", "id": 10}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":10}`,
+		},
+		{
+			name: "parse error",
+			body: `{"jsonrpc": "2.0", "method": "foobar, "params": "bar", "baz]`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`,
+		},
+		{
+			name: "empty batch",
+			body: `[]`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`,
+		},
+	}
+	for _, test := range tests {
+		code, body := post(handler, test.body)
+		if want, have := http.StatusOK, code; want != have {
+			t.Errorf("%s: expected %#v, got %#v", test.name, want, have)
+		}
+		if want, have := test.want+"\n", body; want != have {
+			t.Errorf("%s: expected %#v, got %#v", test.name, want, have)
+		}
+	}
+
+	if want := `at=error method=fail err="database is down"`; !strings.Contains(errs.String(), want) {
+		t.Errorf("expected log to contain %#v, got %#v", want, errs.String())
+	}
+}
+
+func TestServer_batch(t *testing.T) {
+	handler, notified := newServer(t, &bytes.Buffer{})
+
+	code, body := post(handler, `[
+		{"jsonrpc": "2.0", "method": "subtract", "params": [42, 23], "id": "1"},
+		{"jsonrpc": "2.0", "method": "notify", "params": ["hello"]},
+		{"foo": "boo"},
+		{"jsonrpc": "2.0", "method": "foo.get", "params": {"name": "myself"}, "id": "5"},
+		1
+	]`)
+	if want, have := http.StatusOK, code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	want := `[` +
+		`{"jsonrpc":"2.0","result":19,"id":"1"},` +
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null},` +
+		`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":"5"},` +
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}` +
+		`]` + "\n"
+	if have := body; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "hello", strings.Join(*notified, ","); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestServer_notifications(t *testing.T) {
+	handler, notified := newServer(t, &bytes.Buffer{})
+
+	code, body := post(handler, `{"jsonrpc": "2.0", "method": "notify", "params": ["a"]}`)
+	if want, have := http.StatusNoContent, code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "", body; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	code, _ = post(handler, `[
+		{"jsonrpc": "2.0", "method": "notify", "params": ["b"]},
+		{"jsonrpc": "2.0", "method": "notify", "params": ["c"]}
+	]`)
+	if want, have := http.StatusNoContent, code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "a,b,c", strings.Join(*notified, ","); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestServer_methodNotAllowed(t *testing.T) {
+	handler, _ := newServer(t, &bytes.Buffer{})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/rpc", nil))
+	if want, have := http.StatusMethodNotAllowed, w.Code; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "POST", w.Header().Get("Allow"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestServer_Register(t *testing.T) {
+	server := jsonrpc.NewServer()
+	tests := []struct {
+		fn         interface{}
+		paramNames []string
+		want       string
+	}{
+		{
+			fn:   "not a function",
+			want: "jsonrpc: method needs to be a function, got string",
+		},
+		{
+			fn:   func(names ...string) {},
+			want: "jsonrpc: method cannot be a variadic function",
+		},
+		{
+			fn:         func(a, b int) {},
+			paramNames: []string{"a"},
+			want:       "jsonrpc: method takes 2 params, got 1 names",
+		},
+		{
+			fn:   func() (int, int) { return 0, 0 },
+			want: "jsonrpc: method needs to return error as the last variable",
+		},
+	}
+	for _, test := range tests {
+		err := server.Register("method", test.fn, test.paramNames...)
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		} else if want, have := test.want, err.Error(); want != have {
+			t.Errorf("expected %#v, got %#v", want, have)
+		}
+	}
+}